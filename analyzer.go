@@ -22,44 +22,21 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
-	"os"
-	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"text/template"
-	"time"
+	"unicode"
 
 	"golang.org/x/tools/go/analysis"
 )
 
-type CommentStyleType int
-
-const (
-	DoubleSlash CommentStyleType = iota
-	MultiLine
-	MultiLineStar
-)
-
-const iso = "2006-01-02 15:04:05 -0700"
-
-func modTime(path string) (time.Time, error) {
-	diff, err := exec.Command("git", "diff", path).CombinedOutput()
-	if err == nil && len(diff) == 0 {
-		line, err := exec.Command("git", "log", "-1", "--pretty=format:%cd", "--date=iso", "--", path).CombinedOutput()
-		if err == nil {
-			return time.Parse(iso, string(line))
-		}
-	}
-	info, err := os.Stat(path)
-	if err != nil {
-		return time.Time{}, err
-	}
-	return info.ModTime(), nil
-}
-
 type Analyzer struct {
 	Settings *Settings
+
+	modTimes modTimes
+	matchers matcherCache
 }
 
 func New(settings *Settings) *analysis.Analyzer {
@@ -82,23 +59,36 @@ type Result struct {
 	End, Start token.Pos
 }
 
-func (a *Analyzer) skipDirectives(file *ast.File) *ast.CommentGroup {
+// skipDirectives returns the first comment group before the package clause which has text
+// besides directives, see isDirective. If the file has the generated code marker, the header is searched
+// after the marker group if afterMarker is true and before it otherwise. A group found on the other side
+// of the marker is returned as misplaced.
+func (a *Analyzer) skipDirectives(file *ast.File, marker *ast.CommentGroup, afterMarker bool) (header, misplaced *ast.CommentGroup) {
 	for _, comment := range file.Comments {
 		if comment.Pos() > file.Package {
 			break
 		}
-		text := comment.Text()
-		if text == "" || strings.HasPrefix(text, "+build") || strings.HasPrefix(text, "Code generated by cmd/cgo") {
+		if comment == marker {
 			continue
 		}
-		return comment
+		text := (&ast.CommentGroup{List: lineComments(comment)}).Text()
+		if text == "" {
+			continue
+		}
+		if marker != nil && (comment.Pos() > marker.Pos()) != afterMarker {
+			if misplaced == nil {
+				misplaced = comment
+			}
+			continue
+		}
+		return comment, nil
 	}
 
-	return nil
-
+	return nil, misplaced
 }
 
 func (a *Analyzer) Run(pass *analysis.Pass) (any, error) {
+	sources := newSourceFiles(pass.Fset)
 	jobCh := make(chan *ast.File, len(pass.Files))
 
 	for _, f := range pass.Files {
@@ -109,6 +99,8 @@ func (a *Analyzer) Run(pass *analysis.Pass) (any, error) {
 
 	var wg sync.WaitGroup
 	var reportMutex sync.Mutex
+	var errs []error
+	var seenErrs = make(map[string]bool)
 
 	for range a.Settings.Parallel {
 		wg.Add(1)
@@ -122,43 +114,35 @@ func (a *Analyzer) Run(pass *analysis.Pass) (any, error) {
 					continue
 				}
 
-				diag, err := a.Analyze(filename, file)
+				diag, err := a.analyze(sources, filename, file)
 				if err != nil {
-					// TODO handle the error.
-					return
+					switch category := a.Settings.Category(CategoryConfigError); {
+					case category.Disabled:
+						continue
+					case category.Severity != SeverityError:
+						// Report the error for the file instead of failing the run.
+						reportMutex.Lock()
+						pass.Report(a.withSeverity(analysis.Diagnostic{Pos: file.Package, Message: err.Error(), Category: CategoryConfigError}))
+						reportMutex.Unlock()
+						continue
+					}
+
+					// The same misconfiguration usually breaks every file, so keep only the first occurrence.
+					reportMutex.Lock()
+					if !seenErrs[err.Error()] {
+						seenErrs[err.Error()] = true
+						errs = append(errs, fmt.Errorf("%v: %w", filename, err))
+					}
+					reportMutex.Unlock()
+					continue
 				}
 
 				if diag == nil {
 					continue
 				}
 
-				var line = 1
-				if ast.IsGenerated(file) {
-					line = 4
-				}
-
-				fileToken := pass.Fset.File(file.Pos())
-
-				start := fileToken.LineStart(line)
-				endLine := fileToken.Line(diag.End-diag.Pos+start) + line
-
-				var end token.Pos
-				if endLine < fileToken.LineCount() {
-					end = fileToken.LineStart(endLine)
-				} else {
-					end = start
-				}
-
-				diag.Pos = start
-				diag.End = end
-
-				if len(diag.SuggestedFixes) > 0 && len(diag.SuggestedFixes[0].TextEdits) > 0 {
-					diag.SuggestedFixes[0].TextEdits[0].Pos = start
-					diag.SuggestedFixes[0].TextEdits[0].End = end
-				}
-
 				reportMutex.Lock()
-				pass.Report(*diag)
+				pass.Report(a.withSeverity(*diag))
 				reportMutex.Unlock()
 			}
 		}()
@@ -166,94 +150,252 @@ func (a *Analyzer) Run(pass *analysis.Pass) (any, error) {
 
 	wg.Wait()
 
-	return nil, nil
+	slices.SortFunc(errs, func(a, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+
+	return nil, errors.Join(errs...)
 }
 
 func (a *Analyzer) Analyze(path string, file *ast.File) (*analysis.Diagnostic, error) {
-	if a.Settings.Template == "" {
+	return a.analyze(nil, path, file)
+}
+
+// analyze checks the file. If sources is not nil, mismatches refer to the template source in related information.
+func (a *Analyzer) analyze(sources *sourceFiles, path string, file *ast.File) (*analysis.Diagnostic, error) {
+	var raw string
+	var kept []string
+	// linePos returns the range of the header line with the index.
+	var linePos func(i int) (token.Pos, token.Pos)
+
+	var afterMarker = a.Settings.Generated == CheckAfterMarker
+	var marker = generatedMarkerGroup(file)
+
+	if marker != nil && isCgoMarker(marker) {
+		afterMarker = true
+	} else if marker != nil && a.Settings.Generated == SkipGenerated {
 		return nil, nil
 	}
 
-	var header string
-	var style CommentStyleType
+	var comment, misplaced = a.skipDirectives(file, marker, afterMarker)
 
-	var comment = a.skipDirectives(file)
+	if comment == nil && misplaced != nil {
+		if afterMarker {
+			return a.enabled(moveHeader(file, misplaced, "header must be placed after the generated code marker", marker.End(), true)), nil
+		}
+		return a.enabled(moveHeader(file, misplaced, "header must be placed before the generated code marker", marker.Pos(), false)), nil
+	}
 
-	result := &analysis.Diagnostic{}
+	var pos, end token.Pos
 
 	if comment != nil {
 		var list = comment.List
 		if len(list) > 0 && strings.HasPrefix(list[0].Text, "/*") {
-			result.Pos = list[0].Pos()
-			result.End = list[0].End()
+			pos = list[0].Pos()
+			end = list[0].End()
+
+			raw = list[0].Text
+			linePos = func(i int) (token.Pos, token.Pos) {
+				start := lineOffset(raw, i)
+				line, _, _ := strings.Cut(raw[start:], "\n")
+				return pos + token.Pos(start), pos + token.Pos(start+len(line))
+			}
+		} else {
+			// Directives before and after the header stay out of the replaced range.
+			lines := lineComments(comment)
+			pos = lines[0].Pos()
+			end = lines[len(lines)-1].End()
+			kept = keptDirectives(comment, lines)
+
+			var texts []string
+			for _, c := range lines {
+				texts = append(texts, c.Text)
+			}
+			raw = strings.Join(texts, "\n")
+			linePos = func(i int) (token.Pos, token.Pos) {
+				i = min(i, len(lines)-1)
+				return lines[i].Pos(), lines[i].End()
+			}
+		}
+	} else {
+		pos = a.newHeaderPos(file, marker, afterMarker)
+		end = pos
+	}
 
-			header = (&ast.CommentGroup{List: []*ast.Comment{list[0]}}).Text()
-			style = MultiLine
+	// The diagnostic covers the header comments, the replaced range includes the line break after them
+	// if nothing follows on the same line. A single space after a block comment is replaced as well,
+	// that is harmless.
+	var editEnd = end
+	var lineBreak string
+	if comment != nil && !startsToken(file, end) {
+		editEnd++
+		lineBreak = "\n"
+	}
 
-			if handledHeader, ok := handleStarBlock(header); ok {
-				header = handledHeader
-				style = MultiLineStar
+	style := a.detectStyle(goCommentStyles, raw)
+	preferred := a.preferredStyle(goCommentStyles, style)
+	text := style.Strip(raw)
+
+	render := func(text string) string {
+		if comment == nil {
+			return newHeaderText(file, pos, preferred.Render(text))
+		}
+		return strings.Join(append([]string{preferred.Render(text)}, kept...), "\n") + lineBreak
+	}
+
+	checked, err := a.check(path, text, render)
+	result := a.enabled(checked.diag)
+	if result == nil && err == nil && raw != "" && preferred != style {
+		result = a.enabled(wrongStyle(style, preferred, render(strings.TrimSpace(text))))
+	}
+	if result != nil {
+		result.Pos = pos
+		result.End = end
+
+		if checked.line >= 0 {
+			result.Pos, result.End = linePos(checked.line)
+			result.Related = templateRelated(sources, checked.template, checked.lineIndex)
+		}
+
+		for i := range result.SuggestedFixes {
+			for j := range result.SuggestedFixes[i].TextEdits {
+				result.SuggestedFixes[i].TextEdits[j].Pos = pos
+				result.SuggestedFixes[i].TextEdits[j].End = editEnd
 			}
+		}
 
-		} else {
-			result.Pos = comment.Pos()
-			result.End = comment.Pos()
+		if len(checked.years) > 0 && result.Category == CategoryOutdatedYear {
+			setYearEdits(result, checked.years, func(i int) (token.Pos, string) {
+				start, _ := linePos(i)
+				return start, strings.Split(raw, "\n")[i]
+			})
+		}
+	}
+
+	if result == nil && err == nil && comment != nil {
+		result = a.enabled(a.checkPlacement(file, comment))
+	}
+
+	if result == nil && err == nil && comment != nil && a.Settings.CheckPosition {
+		result = a.enabled(checkPosition(file, comment, text, checked.matchEnd))
+	}
 
-			header = comment.Text()
-			style = DoubleSlash
+	return result, err
+}
+
+// checkResult is the result of matching a header against templates.
+type checkResult struct {
+	// diag is nil if the header matches. It has no positions.
+	diag *analysis.Diagnostic
+	// matchEnd is the offset of the end of the matched text in the header or -1.
+	matchEnd int
+	// line is the index of the first mismatching line of the header or -1.
+	line int
+	// lineIndex is the index of the first mismatching line of the template.
+	lineIndex int
+	// template is the closest template if the header doesn't match.
+	template Template
+	// years are set if the header matches a template except the years.
+	years []yearEdit
+}
+
+// check matches the header text without comment markers against templates. The fix of the returned
+// diagnostic is rendered as a comment by render.
+func (a *Analyzer) check(path, header string, render func(text string) string) (checkResult, error) {
+	var result = checkResult{matchEnd: -1, line: -1}
+
+	templates := a.Settings.GetTemplates()
+	values := a.Settings.Values
+
+	if rule := a.Settings.GetRule(path); rule != nil {
+		if rule.Skip {
+			return result, nil
 		}
+		if len(rule.Templates) > 0 {
+			templates = rule.Templates
+		}
+		if rule.Values != nil {
+			values = rule.Values
+		}
+	}
+
+	if len(templates) == 0 {
+		return result, nil
 	}
 
-	vars, err := a.getPerTargetValues(path)
+	vals, err := a.getPerTargetValues(path, values)
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 
+	offset := len(header) - len(strings.TrimLeftFunc(header, unicode.IsSpace))
+	leadingLines := strings.Count(header[:offset], "\n")
 	header = strings.TrimSpace(header)
+	fix := a.fixes(header, vals)
 
 	if header == "" {
-		text, err := a.generateFix(style, vars)
+		text, err := fix(templates[0].Text)
 		if err != nil {
-			return nil, err
+			return result, err
 		}
 
-		result.Message = "missed copyright header"
-		result.SuggestedFixes = append(result.SuggestedFixes, analysis.SuggestedFix{
-			TextEdits: []analysis.TextEdit{{
-				NewText: []byte(text),
+		result.diag = &analysis.Diagnostic{
+			Message:  "missed copyright header",
+			Category: CategoryMissingHeader,
+			SuggestedFixes: []analysis.SuggestedFix{{
+				TextEdits: []analysis.TextEdit{{
+					NewText: []byte(render(text)),
+				}},
 			}},
-		})
+		}
 
 		return result, nil
 	}
 
-	templateRaw := a.quoteMeta(a.Settings.Template)
+	var closest Template
+	var bestScore = -1
 
-	tmpl, err := template.New("header").Delims(a.Settings.LeftDelim, a.Settings.RightDelim).Parse(templateRaw)
-	if err != nil {
-		return nil, err
-	}
+	for _, t := range templates {
+		loc, err := a.match(t.Text, header, vals)
+		if err != nil {
+			return result, err
+		}
 
-	headerTemplateBuffer := new(bytes.Buffer)
+		if loc != nil {
+			result.matchEnd = offset + loc[1]
+			return result, nil
+		}
 
-	err = tmpl.Execute(headerTemplateBuffer, vars)
-	if err != nil {
-		return nil, err
+		if score := a.similarity(t.Text, header, vals); score > bestScore {
+			bestScore = score
+			closest = t
+		}
 	}
 
-	exp, err := regexp.Compile(headerTemplateBuffer.String())
-	if err != nil {
-		return nil, err
-	}
+	for _, t := range templates {
+		years, templateLine := a.outdatedYears(t.Text, header, vals.vars)
+		if len(years) == 0 {
+			continue
+		}
 
-	if !exp.MatchString(header) {
-		text, _ := a.generateFix(style, vars)
+		for i := range years {
+			years[i].line += leadingLines
+		}
+
+		result.template = t
+		result.years = years
+		result.line = years[0].line
+		result.lineIndex = templateLine
+		result.diag = &analysis.Diagnostic{
+			Message:  fmt.Sprintf("copyright year outdated: found %v, expected %v", years[0].found, years[0].expected),
+			Category: CategoryOutdatedYear,
+		}
 
-		result.Message = "template doesn't match"
-		if text != "" {
-			result.SuggestedFixes = append(result.SuggestedFixes, analysis.SuggestedFix{
+		// The whole header is replaced if the years can't be edited in place.
+		if text, err := fix(t.Text); err == nil {
+			result.diag.SuggestedFixes = append(result.diag.SuggestedFixes, analysis.SuggestedFix{
 				TextEdits: []analysis.TextEdit{{
-					NewText: []byte(text),
+					NewText: []byte(render(text)),
 				}},
 			})
 		}
@@ -261,70 +403,265 @@ func (a *Analyzer) Analyze(path string, file *ast.File) (*analysis.Diagnostic, e
 		return result, nil
 	}
 
-	return nil, nil
+	result.template = closest
+	result.diag = &analysis.Diagnostic{Message: "template doesn't match", Category: CategoryTemplateMismatch}
+	if len(templates) > 1 {
+		result.diag.Message = fmt.Sprintf("template doesn't match any of %v templates, the closest is %q", len(templates), closest.Name)
+	}
+
+	if m, ok := a.mismatch(closest.Text, header, vals, fix); ok {
+		result.line = leadingLines + m.line
+		result.lineIndex = m.templateLine
+		switch {
+		case m.expected == nil:
+		case m.found == nil:
+			result.diag.Message += fmt.Sprintf(": expected %q after the last line", *m.expected)
+		default:
+			result.diag.Message += fmt.Sprintf(": expected %q, found %q", *m.expected, *m.found)
+		}
+	}
+
+	if text, err := fix(templates[0].Text); err == nil {
+		result.diag.SuggestedFixes = append(result.diag.SuggestedFixes, analysis.SuggestedFix{
+			TextEdits: []analysis.TextEdit{{
+				NewText: []byte(render(text)),
+			}},
+		})
+	}
+
+	return result, nil
+}
+
+// lineMismatch describes the first template line that doesn't match the header.
+type lineMismatch struct {
+	// line is the index of the header line, templateLine is the index of the template line.
+	line, templateLine int
+	// expected is nil if the fix can't be aligned with the template.
+	expected *string
+	// found is nil if the header has no more lines.
+	found *string
+}
+
+// mismatch finds the first line of the template that doesn't match the header. Template and header lines
+// are aligned by the line matcher, lines of blocks and optional lines are skipped. The expected text is the line
+// of the fix.
+func (a *Analyzer) mismatch(tmplText, header string, vals targetValues, fix func(tmplText string) (string, error)) (lineMismatch, bool) {
+	nodes, err := a.lineNodes(tmplText, vals)
+	if err != nil {
+		return lineMismatch{}, false
+	}
+
+	headerLines := strings.Split(header, "\n")
+
+	var failed *lineNode
+	var failedAt int
+
+	w := &lineWalk{a: a, lines: headerLines, fail: func(n lineNode, j int) {
+		if n.line >= 0 && !n.optional && (failed == nil || j > failedAt) {
+			failed, failedAt = &n, j
+		}
+	}}
+
+	if w.match(nodes, 0, a.matchEnd(len(headerLines))) >= 0 || failed == nil {
+		return lineMismatch{}, false
+	}
+
+	m := lineMismatch{line: failedAt, templateLine: failed.line}
+
+	if failedAt < len(headerLines) {
+		found := strings.TrimSpace(headerLines[failedAt])
+		m.found = &found
+	} else {
+		m.line = len(headerLines) - 1
+	}
+
+	// Lines of the fix are the template lines besides blocks.
+	var index, count int
+	for _, n := range nodes {
+		if n.exp == nil {
+			continue
+		}
+		if n.line < failed.line {
+			index++
+		}
+		count++
+	}
+
+	if text, err := fix(tmplText); err == nil {
+		if fixLines := strings.Split(text, "\n"); len(fixLines) == count {
+			expected := strings.TrimSpace(fixLines[index])
+			m.expected = &expected
+		}
+	} else if text, err := fix(failed.text); err == nil && !strings.Contains(text, "\n") {
+		// Other lines can't be fixed, e.g. optional lines with regexp values.
+		expected := strings.TrimSpace(text)
+		m.expected = &expected
+	}
+
+	return m, true
 }
 
-func (a *Analyzer) generateFix(style CommentStyleType, vals map[string]Value) (string, error) {
-	// TODO: add values for quick fixes in config
-	vals["YEAR_RANGE"] = vals["YEAR"]
-	vals["MOD_YEAR_RANGE"] = vals["YEAR"]
+// compile returns the regexp for the template. Regexps are cached for the values.
+func (a *Analyzer) compile(tmplText string, vals targetValues) (*regexp.Regexp, error) {
+	return a.matchers.getExp(tmplText, "", vals, func() (*regexp.Regexp, error) {
+		expr, err := a.expression(tmplText, vals.vars)
+		if err != nil {
+			return nil, err
+		}
 
-	for _, v := range vals {
-		if _, ok := v.(*RegexpValue); ok {
-			return "", errors.New("fixes are not supported for regexp values. See more details https://github.com/denis-tingaikin/go-header/issues/52")
+		return regexp.Compile(expr)
+	})
+}
+
+// similarity returns the number of template lines that match the header line at the same position.
+// Lines that can't be compiled on their own (e.g. a value spans several lines) are not counted.
+func (a *Analyzer) similarity(tmplText, header string, vals targetValues) int {
+	var score int
+
+	tmplLines := strings.Split(tmplText, "\n")
+	headerLines := strings.Split(header, "\n")
+
+	for i := 0; i < len(tmplLines) && i < len(headerLines); i++ {
+		exp, err := a.compileLine(tmplLines[i], vals)
+		if err != nil {
+			continue
+		}
+		if exp.MatchString(a.normalizeLine(headerLines[i])) {
+			score++
 		}
-		_ = v.Calculate(vals)
 	}
 
-	fixTemplate, err := template.New("fix").Parse(a.Settings.Template)
+	return score
+}
+
+// compileLine compiles a template line to match a whole header line normalized by normalizeLine.
+func (a *Analyzer) compileLine(tmplLine string, vals targetValues) (*regexp.Regexp, error) {
+	tmplLine = a.normalizeLine(tmplLine)
+
+	return a.matchers.getExp(tmplLine, "line", vals, func() (*regexp.Regexp, error) {
+		exp, err := a.compile(tmplLine, vals)
+		if err != nil {
+			return nil, err
+		}
+		return regexp.Compile("^(?:" + exp.String() + ")$")
+	})
+}
+
+// fixes returns a function that renders templates for fixes of the header. Each template is rendered once.
+func (a *Analyzer) fixes(header string, vals targetValues) func(tmplText string) (string, error) {
+	type fixResult struct {
+		text string
+		err  error
+	}
+
+	var results = make(map[string]fixResult)
+
+	return func(tmplText string) (string, error) {
+		r, ok := results[tmplText]
+		if !ok {
+			r.text, r.err = a.generateFix(tmplText, vals, header)
+			results[tmplText] = r
+		}
+		return r.text, r.err
+	}
+}
+
+// generateFix renders the template for a fix. The result has no comment markers.
+func (a *Analyzer) generateFix(tmplText string, vals targetValues, header string) (string, error) {
+	f := newFixer(vals.vars, a.capture(tmplText, header, vals))
+
+	text, err := a.rewriteBlocks(tmplText, dropSection)
+	if err != nil {
+		return "", err
+	}
+
+	fixTemplate, err := template.New("fix").Delims(a.Settings.LeftDelim, a.Settings.RightDelim).Parse(text)
 	if err != nil {
 		return "", err
 	}
 
 	fixOut := new(bytes.Buffer)
-	err = fixTemplate.Execute(fixOut, vals)
+	err = fixTemplate.Execute(fixOut, f.data())
 	if err != nil {
 		return "", err
 	}
+	if f.err != nil {
+		return "", f.err
+	}
 
-	resSplit := strings.Split(fixOut.String(), "\n")
+	return fixOut.String(), nil
+}
 
-	for i := range resSplit {
-		switch style {
-		case DoubleSlash:
-			resSplit[i] = "// " + resSplit[i]
-		case MultiLineStar:
-			resSplit[i] = " * " + resSplit[i]
-		case MultiLine:
-			continue
+// getPerTargetValues returns values for the file. Files with the same years share the result,
+// it must not be changed.
+func (a *Analyzer) getPerTargetValues(path string, values map[string]Value) (targetValues, error) {
+	key := valuesKey{
+		values: valuesFingerprint(values),
+		year:   fmt.Sprint(a.Settings.now().Year()),
+		policy: a.Settings.YearRangePolicy,
+	}
+
+	times, err := a.modTimes.get(path, a.Settings.DateSource)
+	if err == nil && times.source == ModTimeFromFileSystem && a.Settings.Now != nil {
+		// A fixed reference time makes results reproducible, so fresh checkouts shouldn't go past it.
+		if now := a.Settings.now(); times.modified.After(now) {
+			times.modified = now
 		}
 	}
+	if err == nil {
+		key.modYear = fmt.Sprint(times.modified.Year())
+	}
+	if !times.created.IsZero() {
+		key.createdYear = fmt.Sprint(times.created.Year())
+	}
 
-	switch style {
-	case MultiLineStar:
-		resSplit = append([]string{"/*"}, resSplit...)
-		resSplit = append(resSplit, " */")
-	case MultiLine:
-		resSplit = append([]string{"/*"}, resSplit...)
-		resSplit = append(resSplit, "*/")
+	if a.Settings.ModTimeReporter != nil {
+		if err != nil {
+			times.modified, times.source = a.Settings.now(), ModTimeFromNow
+		}
+		a.Settings.ModTimeReporter(path, times.modified, times.source)
 	}
 
-	return strings.Join(resSplit, "\n") + "\n", nil
+	vars, err := a.matchers.getValues(key, func() (map[string]Value, error) {
+		return calculateValues(values, key)
+	})
+
+	return targetValues{key: key, vars: vars}, err
 }
 
-func (a *Analyzer) getPerTargetValues(path string) (map[string]Value, error) {
-	var res = make(map[string]Value, len(a.Settings.Values))
+// calculateValues clones values and adds year values of the key. YEAR and YEAR_RANGE are added
+// if the values don't have them.
+func calculateValues(values map[string]Value, key valuesKey) (map[string]Value, error) {
+	var res = make(map[string]Value, len(values))
 
-	for k, v := range a.Settings.Values {
+	for k, v := range values {
 		res[k] = v.Clone()
 	}
 
-	res["MOD_YEAR"] = a.Settings.Values["YEAR"].Clone()
-	res["MOD_YEAR_RANGE"] = a.Settings.Values["YEAR_RANGE"].Clone()
+	if _, ok := res["YEAR"]; !ok {
+		res["YEAR"] = &ConstValue{RawValue: key.year}
+	}
+	if _, ok := res["YEAR_RANGE"]; !ok {
+		res["YEAR_RANGE"] = &YearRangeValue{RawValue: "{{.YEAR}}"}
+	}
+
+	res["MOD_YEAR"] = res["YEAR"].Clone()
+	res["MOD_YEAR_RANGE"] = res["YEAR_RANGE"].Clone()
 
-	if t, err := modTime(path); err == nil {
-		res["MOD_YEAR"] = &ConstValue{RawValue: fmt.Sprint(t.Year())}
-		res["MOD_YEAR_RANGE"] = &RegexpValue{RawValue: `((20\d\d\-{{.MOD_YEAR}})|({{.MOD_YEAR}}))`}
+	if key.modYear != "" {
+		res["MOD_YEAR"] = &ConstValue{RawValue: key.modYear}
+		res["MOD_YEAR_RANGE"] = &YearRangeValue{RawValue: "{{.MOD_YEAR}}", Fix: "{{.YEAR}}"}
+	}
+
+	res["CREATED_YEAR"] = res["MOD_YEAR"].Clone()
+	if key.createdYear != "" {
+		res["CREATED_YEAR"] = &ConstValue{RawValue: key.createdYear}
+	}
+
+	for _, v := range res {
+		if r, ok := v.(*YearRangeValue); ok && r.Policy == "" {
+			r.Policy = key.policy
+		}
 	}
 
 	for _, v := range res {
@@ -370,30 +707,3 @@ func (a *Analyzer) quoteMeta(text string) string {
 
 	return result.String()
 }
-
-func handleStarBlock(header string) (string, bool) {
-	var handled = false
-	return trimEachLine(header, func(s string) string {
-		var trimmed = strings.TrimSpace(s)
-		if !strings.HasPrefix(trimmed, "*") {
-			return s
-		}
-		if v, ok := strings.CutPrefix(trimmed, "* "); ok {
-			handled = true
-			return v
-		} else {
-			var res, _ = strings.CutPrefix(trimmed, "*")
-			return res
-		}
-	}), handled
-}
-
-func trimEachLine(input string, trimFunc func(string) string) string {
-	lines := strings.Split(input, "\n")
-
-	for i, line := range lines {
-		lines[i] = trimFunc(line)
-	}
-
-	return strings.Join(lines, "\n")
-}
@@ -22,14 +22,18 @@ import (
 	"go/parser"
 	"go/token"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	goheader "github.com/denis-tingaikin/go-header"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
 )
 
@@ -50,6 +54,13 @@ func TestAnalyzer(t *testing.T) {
 		{name: "starcomment", cfgFilename: "starcomment.yml"},
 		{name: "unicodeheader", cfgFilename: "unicodeheader.yml"},
 		{name: "gobuild", cfgFilename: "gobuild.yml"},
+		{name: "multitemplate", cfgFilename: "multitemplate.yml"},
+		{name: "rules", cfgFilename: "rules.yml"},
+		{name: "position", cfgFilename: "position.yml"},
+		{name: "mismatchline", cfgFilename: "mismatchline.yml"},
+		{name: "listvar", cfgFilename: "listvar.yml"},
+		{name: "blocks", cfgFilename: "blocks.yml"},
+		{name: "blocklines", cfgFilename: "blocklines.yml"},
 	}
 
 	for _, test := range testCases {
@@ -81,7 +92,12 @@ func TestAnalyzer_fix(t *testing.T) {
 		{dir: "fix", cfgFilename: "fix.yml"},
 		{dir: "sample", cfgFilename: "sample.yml"},
 		{dir: "noheader", cfgFilename: "noheader.yml"},
-		// {dir: "regexpvalue_issue", cfgFilename: "regexpvalue_issue.yml"}, // TODO: https://github.com/denis-tingaikin/go-header/issues/52
+		{dir: "regexpvalue_issue", cfgFilename: "regexpvalue_issue.yml"},
+		{dir: "varfix", cfgFilename: "varfix.yml"},
+		{dir: "preserve", cfgFilename: "preserve.yml"},
+		{dir: "commentstyle", cfgFilename: "commentstyle.yml"},
+		{dir: "preferstyle", cfgFilename: "preferstyle.yml"},
+		{dir: "listvar", cfgFilename: "listvar.yml"},
 	}
 
 	testdata := analysistest.TestData()
@@ -121,13 +137,149 @@ func TestAnalyzer_fix(t *testing.T) {
 				require.Len(t, diag.SuggestedFixes, 1)
 				require.Len(t, diag.SuggestedFixes[0].TextEdits, 1)
 
-				expected := extractGolden(t, filepath.Join(testdata, test.dir, entry.Name()+".golden"))
-				assert.Equal(t, expected, string(diag.SuggestedFixes[0].TextEdits[0].NewText))
+				src, err := os.ReadFile(srcFile)
+				require.NoError(t, err)
+				golden, err := os.ReadFile(srcFile + ".golden")
+				require.NoError(t, err)
+				fixed := applyFix(fs, string(src), diag.SuggestedFixes[0])
+				assert.Equal(t, string(golden), fixed)
+
+				// The fixed file must pass, otherwise the fix is suggested on every run.
+				fixedFile, err := parser.ParseFile(token.NewFileSet(), srcFile, fixed, parser.ParseComments)
+				require.NoError(t, err)
+
+				diag, err = gh.Analyze(srcFile, fixedFile)
+				require.NoError(t, err)
+				require.Nil(t, diag, fixed)
 			})
 		}
 	}
 }
 
+func TestAnalyzer_SuggestedFixRanges(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	cfg, err := goheader.Parse(filepath.Join(testdata, "src", "fixranges", "fixranges.yml"))
+	require.NoError(t, err)
+
+	settings := &goheader.Settings{}
+	require.NoError(t, cfg.FillSettings(settings))
+
+	analysistest.RunWithSuggestedFixes(t, testdata, goheader.New(settings), "fixranges")
+}
+
+func TestAnalyzer_FixRangeShouldCoverHeader(t *testing.T) {
+	settings := &goheader.Settings{}
+	settings.SetDelimiters("", "")
+	settings.SetValues(nil)
+	settings.Template = "Copyright Acme"
+
+	a := goheader.Analyzer{Settings: settings}
+
+	const src = "//go:build linux\n\n// Copyright Other\n// Second line\n\npackage a\n"
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "a.go", src, parser.ParseComments)
+	require.NoError(t, err)
+
+	diag, err := a.Analyze("a.go", file)
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+
+	edit := diag.SuggestedFixes[0].TextEdits[0]
+	start, end := fset.Position(edit.Pos).Offset, fset.Position(edit.End).Offset
+	require.Equal(t, "// Copyright Other\n// Second line\n", src[start:end])
+}
+
+func TestAnalyzer_MismatchShouldReferToTemplate(t *testing.T) {
+	testdata := analysistest.TestData()
+	cfgPath := filepath.Join(testdata, "src", "mismatchline", "mismatchline.yml")
+
+	cfg, err := goheader.Parse(cfgPath)
+	require.NoError(t, err)
+
+	settings := &goheader.Settings{}
+	require.NoError(t, cfg.FillSettings(settings))
+
+	results := analysistest.Run(t, testdata, goheader.New(settings), "mismatchline")
+	require.Len(t, results, 1)
+
+	for _, diag := range results[0].Diagnostics {
+		require.Len(t, diag.Related, 1)
+
+		position := results[0].Pass.Fset.Position(diag.Related[0].Pos)
+		require.Equal(t, cfgPath, position.Filename)
+		require.Equal(t, 3, position.Line)
+		require.Equal(t, `template "default"`, diag.Related[0].Message)
+	}
+}
+
+func TestAnalyzer_MismatchMessage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		header   string
+		message  string
+	}{
+		{
+			name:     "block is skipped",
+			template: "Copyright {{ .YEAR }} Acme\n{{ optional }}Modified by {{ .AUTHOR }}{{ end }}\nSPDX-License-Identifier: MIT",
+			header:   "Copyright 2026 Acme\nApache",
+			message:  `template doesn't match: expected "SPDX-License-Identifier: MIT", found "Apache"`,
+		},
+		{
+			name:     "line after repeated lines",
+			template: "A\n{{ repeat }}B{{ end }}\nC {{ .YEAR }}",
+			header:   "A\nB\nB\nD",
+			message:  `template doesn't match: expected "C 2026", found "D"`,
+		},
+		{
+			name:     "missing line after block",
+			template: "A\n{{ repeat }}B{{ end }}\nC {{ .YEAR }}",
+			header:   "A\nB",
+			message:  `template doesn't match: expected "C 2026" after the last line`,
+		},
+		{
+			name:     "optional line is skipped",
+			template: "Copyright Acme\nModified by {{ .AUTHOR }}\nSPDX-License-Identifier: MIT",
+			header:   "Copyright Acme\nApache",
+			message:  `template doesn't match: expected "SPDX-License-Identifier: MIT", found "Apache"`,
+		},
+		{
+			name:     "fix with other lines",
+			template: "A {{ .TEXT }}\nB",
+			header:   "A 42\nB",
+			message:  "template doesn't match",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			settings := &goheader.Settings{
+				Template: test.template,
+				Match:    goheader.MatchExact,
+				Matcher: goheader.MatcherSettings{
+					Type:          goheader.LineMatcher,
+					OptionalLines: []string{"Modified by {{ .AUTHOR }}"},
+				},
+				Now: func() time.Time {
+					return time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+				},
+			}
+			settings.SetDelimiters("", "")
+			settings.SetValues(map[string]string{"AUTHOR": "[A-Z][a-z]+"})
+			settings.Values["TEXT"] = &goheader.RegexpValue{RawValue: "[a-z]+", Fix: "first\nsecond"}
+
+			a := goheader.Analyzer{Settings: settings}
+
+			diag, err := a.Analyze(header(t, "/*\n"+test.header+"\n*/"))
+			require.NoError(t, err)
+			require.NotNil(t, diag)
+			require.Equal(t, test.message, diag.Message)
+		})
+	}
+}
+
 func TestAnalyzer_YearRangeValue_ShouldWorkWithComplexVariables(t *testing.T) {
 	var cfg goheader.Config
 
@@ -154,19 +306,1311 @@ func TestAnalyzer_YearRangeValue_ShouldWorkWithComplexVariables(t *testing.T) {
 	require.Nil(t, diag)
 }
 
-func extractGolden(t *testing.T, filename string) string {
+func TestAnalyzer_MultipleTemplates_ShouldReportClosest(t *testing.T) {
+	settings := &goheader.Settings{}
+	settings.SetDelimiters("", "")
+	settings.SetValues(nil)
+	settings.Templates = []goheader.Template{
+		{Name: "apache", Text: "Copyright Acme\nSPDX-License-Identifier: Apache-2.0"},
+		{Name: "mit", Text: "Copyright Acme\nSPDX-License-Identifier: MIT"},
+	}
+
+	a := goheader.Analyzer{Settings: settings}
+
+	diag, err := a.Analyze(header(t, "/*\nCopyright Acme\nSPDX-License-Identifier: MIT\n*/"))
+	require.NoError(t, err)
+	require.Nil(t, diag)
+
+	diag, err = a.Analyze(header(t, "/*\nCopyright Acme Inc.\nSPDX-License-Identifier: MIT\n*/"))
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+	require.Equal(t, `template doesn't match any of 2 templates, the closest is "mit": expected "Copyright Acme", found "Copyright Acme Inc."`, diag.Message)
+	require.Len(t, diag.SuggestedFixes, 1)
+	require.Equal(t, "/*\nCopyright Acme\nSPDX-License-Identifier: Apache-2.0\n*/\n", string(diag.SuggestedFixes[0].TextEdits[0].NewText))
+}
+
+func TestAnalyzer_CommentStyle(t *testing.T) {
+	settings := &goheader.Settings{}
+	settings.SetDelimiters("", "")
+	settings.SetValues(nil)
+	settings.Template = "Copyright Acme\n\nSPDX-License-Identifier: MIT"
+	settings.CommentStyle = goheader.LineComment
+
+	a := goheader.Analyzer{Settings: settings}
+
+	diag, err := a.Analyze(header(t, "// Copyright Acme\n//\n// SPDX-License-Identifier: MIT"))
+	require.NoError(t, err)
+	require.Nil(t, diag)
+
+	diag, err = a.Analyze(header(t, "/*\n * Copyright Acme\n *\n * SPDX-License-Identifier: MIT\n */"))
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+	require.Equal(t, "header uses block-star comments, expected line", diag.Message)
+	require.Equal(t, "// Copyright Acme\n//\n// SPDX-License-Identifier: MIT\n", string(diag.SuggestedFixes[0].TextEdits[0].NewText))
+
+	diag, err = a.Analyze(header(t, "/*\nCopyright Acme Inc.\n*/"))
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+	require.Equal(t, `template doesn't match: expected "Copyright Acme", found "Copyright Acme Inc."`, diag.Message)
+	require.Equal(t, "// Copyright Acme\n//\n// SPDX-License-Identifier: MIT\n", string(diag.SuggestedFixes[0].TextEdits[0].NewText))
+}
+
+func TestAnalyzer_CheckPosition(t *testing.T) {
+	testCases := []struct {
+		name, src, message, fixed string
+	}{
+		{
+			name: "separated",
+			src:  "// Copyright Acme\n\n// Package a does things.\npackage a\n",
+		},
+		{
+			name:    "attached",
+			src:     "// Copyright Acme\npackage a\n",
+			message: "header must be separated from the package clause by a blank line",
+			fixed:   "// Copyright Acme\n\npackage a\n",
+		},
+		{
+			name:    "merged",
+			src:     "// Copyright Acme\n// Package a does things.\npackage a\n",
+			message: "header is merged with the package doc comment",
+			fixed:   "// Copyright Acme\n\n// Package a does things.\npackage a\n",
+		},
+		{
+			name:    "merged block",
+			src:     "/*\n * Copyright Acme\n *\n * Package a does things.\n */\npackage a\n",
+			message: "header is merged with the package doc comment",
+			fixed:   "/*\n * Copyright Acme\n */\n\n/*\n *\n * Package a does things.\n */\npackage a\n",
+		},
+	}
+
+	settings := &goheader.Settings{}
+	settings.SetDelimiters("", "")
+	settings.SetValues(nil)
+	settings.Template = "Copyright Acme"
+	settings.CheckPosition = true
+
+	a := goheader.Analyzer{Settings: settings}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "a.go", test.src, parser.ParseComments)
+			require.NoError(t, err)
+
+			diag, err := a.Analyze("a.go", file)
+			require.NoError(t, err)
+
+			if test.message == "" {
+				require.Nil(t, diag)
+				return
+			}
+
+			require.NotNil(t, diag)
+			require.Equal(t, test.message, diag.Message)
+
+			require.Equal(t, test.fixed, applyFix(fset, test.src, diag.SuggestedFixes[0]))
+		})
+	}
+}
+
+func TestAnalyzer_HeaderPlacement(t *testing.T) {
+	testCases := []struct {
+		name      string
+		placement goheader.HeaderPlacement
+		src       string
+		message   string
+		fixed     string
+	}{
+		{
+			name:      "any",
+			placement: goheader.AnyPlacement,
+			src:       "//go:build linux\n\n// Copyright Acme\n\npackage a\n",
+		},
+		{
+			name:      "after",
+			placement: goheader.AfterBuildConstraints,
+			src:       "// Copyright Acme\n\n//go:build linux\n\npackage a\n",
+			message:   "header must be placed after build constraints",
+			fixed:     "//go:build linux\n\n// Copyright Acme\n\npackage a\n",
+		},
+		{
+			name:      "before",
+			placement: goheader.BeforeBuildConstraints,
+			src:       "//go:build linux\n// +build linux\n\n// Copyright Acme\n\npackage a\n",
+			message:   "header must be placed before build constraints",
+			fixed:     "// Copyright Acme\n\n//go:build linux\n// +build linux\n\npackage a\n",
+		},
+		{
+			name:      "missing after",
+			placement: goheader.AfterBuildConstraints,
+			src:       "//go:build linux\n\npackage a\n",
+			message:   "missed copyright header",
+			fixed:     "//go:build linux\n\n// Copyright Acme\n\npackage a\n",
+		},
+		{
+			name:      "missing before",
+			placement: goheader.BeforeBuildConstraints,
+			src:       "//go:build linux\n\npackage a\n",
+			message:   "missed copyright header",
+			fixed:     "// Copyright Acme\n\n//go:build linux\n\npackage a\n",
+		},
+		{
+			name:      "directives are kept",
+			placement: goheader.AnyPlacement,
+			src:       "//go:generate stringer\n// Copyright Other\n//nolint:lll\n// Something\n//lint:file-ignore U1000 reason\n\npackage a\n",
+			message:   `template doesn't match: expected "Copyright Acme", found "Copyright Other"`,
+			fixed:     "//go:generate stringer\n// Copyright Acme\n//nolint:lll\n//lint:file-ignore U1000 reason\n\npackage a\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			settings := &goheader.Settings{}
+			settings.SetDelimiters("", "")
+			settings.SetValues(nil)
+			settings.Template = "Copyright Acme"
+			settings.HeaderPlacement = test.placement
+
+			a := goheader.Analyzer{Settings: settings}
+
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "a.go", test.src, parser.ParseComments)
+			require.NoError(t, err)
+
+			diag, err := a.Analyze("a.go", file)
+			require.NoError(t, err)
+
+			if test.message == "" {
+				require.Nil(t, diag)
+				return
+			}
+
+			require.NotNil(t, diag)
+			require.Equal(t, test.message, diag.Message)
+			require.Equal(t, test.fixed, applyFix(fset, test.src, diag.SuggestedFixes[0]))
+		})
+	}
+}
+
+func TestAnalyzer_Generated(t *testing.T) {
+	const protoc = "// Code generated by protoc-gen-go. DO NOT EDIT.\n// versions:\n// \tprotoc-gen-go v1.36.0\n// source: a.proto\n"
+
+	testCases := []struct {
+		name    string
+		policy  goheader.GeneratedPolicy
+		src     string
+		message string
+		fixed   string
+	}{
+		{
+			name:   "skip",
+			policy: goheader.SkipGenerated,
+			src:    "// Code generated by stringer; DO NOT EDIT.\n\npackage a\n",
+		},
+		{
+			name:   "check",
+			policy: goheader.CheckGenerated,
+			src:    "// Copyright Acme\n\n// Code generated by stringer; DO NOT EDIT.\n\npackage a\n",
+		},
+		{
+			name:    "check missing",
+			policy:  goheader.CheckGenerated,
+			src:     "// Code generated by stringer; DO NOT EDIT.\n\npackage a\n",
+			message: "missed copyright header",
+			fixed:   "// Copyright Acme\n\n// Code generated by stringer; DO NOT EDIT.\n\npackage a\n",
+		},
+		{
+			name:    "check misplaced",
+			policy:  goheader.CheckGenerated,
+			src:     "// Code generated by mockgen. DO NOT EDIT.\n\n// Copyright Acme\n\npackage a\n",
+			message: "header must be placed before the generated code marker",
+			fixed:   "// Copyright Acme\n\n// Code generated by mockgen. DO NOT EDIT.\n\npackage a\n",
+		},
+		{
+			name:   "after marker",
+			policy: goheader.CheckAfterMarker,
+			src:    protoc + "\n// Copyright Acme\n\npackage a\n",
+		},
+		{
+			name:    "after marker missing",
+			policy:  goheader.CheckAfterMarker,
+			src:     protoc + "\npackage a\n",
+			message: "missed copyright header",
+			fixed:   protoc + "\n// Copyright Acme\n\npackage a\n",
+		},
+		{
+			name:    "after marker misplaced",
+			policy:  goheader.CheckAfterMarker,
+			src:     "// Copyright Acme\n\n" + protoc + "\npackage a\n",
+			message: "header must be placed after the generated code marker",
+			fixed:   protoc + "\n// Copyright Acme\n\npackage a\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			settings := &goheader.Settings{}
+			settings.SetDelimiters("", "")
+			settings.SetValues(nil)
+			settings.Template = "Copyright Acme"
+			settings.Generated = test.policy
+
+			a := goheader.Analyzer{Settings: settings}
+
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "a.go", test.src, parser.ParseComments)
+			require.NoError(t, err)
+
+			diag, err := a.Analyze("a.go", file)
+			require.NoError(t, err)
+
+			if test.message == "" {
+				require.Nil(t, diag)
+				return
+			}
+
+			require.NotNil(t, diag)
+			require.Equal(t, test.message, diag.Message)
+			require.Equal(t, test.fixed, applyFix(fset, test.src, diag.SuggestedFixes[0]))
+		})
+	}
+}
+
+func TestAnalyzer_OutdatedYear(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		src      string
+		message  string
+		fixed    string
+		// reported is the text at the diagnostic position.
+		reported string
+	}{
+		{
+			name:     "line",
+			template: "Copyright {{ .YEAR }} Acme\n\nSPDX-License-Identifier: MIT",
+			src:      "// Copyright 2024 Acme\n//\n// SPDX-License-Identifier: MIT\n\npackage a\n",
+			message:  "copyright year outdated: found 2024, expected 2026",
+			fixed:    "// Copyright 2026 Acme\n//\n// SPDX-License-Identifier: MIT\n\npackage a\n",
+			reported: "2024",
+		},
+		{
+			name:     "block",
+			template: "Copyright  {{ .YEAR_RANGE }}   Acme",
+			src:      "/*\n *   Copyright  2019-2024   Acme\n */\n\npackage a\n",
+			message:  "copyright year outdated: found 2019-2024, expected 2019-2026",
+			fixed:    "/*\n *   Copyright  2019-2026   Acme\n */\n\npackage a\n",
+			reported: "2019-2024",
+		},
+		{
+			name:     "several years",
+			template: "Copyright {{ .YEAR }} Acme\nUpdated {{ .YEAR }}",
+			src:      "// Copyright 2025 Acme\n// Updated 2024\npackage a\n",
+			message:  "copyright year outdated: found 2025, expected 2026",
+			fixed:    "// Copyright 2026 Acme\n// Updated 2026\npackage a\n",
+			reported: "2025",
+		},
+		{
+			name:     "other changes",
+			template: "Copyright {{ .YEAR }} Acme",
+			src:      "// Copyright 2024 Other\n\npackage a\n",
+			message:  `template doesn't match: expected "Copyright 2026 Acme", found "Copyright 2024 Other"`,
+			fixed:    "// Copyright 2026 Acme\n\npackage a\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			settings := &goheader.Settings{
+				Template: test.template,
+				Now: func() time.Time {
+					return time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+				},
+			}
+			settings.SetDelimiters("", "")
+			settings.SetValues(nil)
+
+			a := goheader.Analyzer{Settings: settings}
+
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "a.go", test.src, parser.ParseComments)
+			require.NoError(t, err)
+
+			diag, err := a.Analyze("a.go", file)
+			require.NoError(t, err)
+			require.NotNil(t, diag)
+			require.Equal(t, test.message, diag.Message)
+			require.Equal(t, test.fixed, applyFix(fset, test.src, diag.SuggestedFixes[0]))
+
+			if test.reported != "" {
+				require.Equal(t, goheader.CategoryOutdatedYear, diag.Category)
+				require.Equal(t, test.reported, test.src[fset.Position(diag.Pos).Offset:fset.Position(diag.End).Offset])
+			}
+		})
+	}
+}
+
+func TestAnalyzer_MatchMode(t *testing.T) {
+	const valid = "Copyright {{ .YEAR }} Acme\nSPDX-License-Identifier: MIT"
+
+	testCases := []struct {
+		header  string
+		mode    goheader.MatchMode
+		message string
+	}{
+		{header: "Hacked by X\nCopyright 2026 Acme\nSPDX-License-Identifier: MIT\nmore"},
+		{header: "Hacked by X\nCopyright 2026 Acme\nSPDX-License-Identifier: MIT", mode: goheader.MatchPrefix, message: "template doesn't match"},
+		{header: "Copyright 2026 Acme\nSPDX-License-Identifier: MIT\nmore", mode: goheader.MatchPrefix},
+		{header: "Copyright 2026 Acme\nSPDX-License-Identifier: MIT\nmore", mode: goheader.MatchExact, message: "template doesn't match"},
+		{header: "Copyright 2026 Acme\nSPDX-License-Identifier: MIT", mode: goheader.MatchExact},
+		{header: "Copyright 2025 Acme\nSPDX-License-Identifier: MIT", mode: goheader.MatchExact, message: "copyright year outdated"},
+		{header: "Copyright 2025 Acme\nSPDX-License-Identifier: MIT\nmore", mode: goheader.MatchExact, message: "template doesn't match"},
+	}
+
+	for _, test := range testCases {
+		t.Run(fmt.Sprintf("%v %q", test.mode, test.header), func(t *testing.T) {
+			settings := &goheader.Settings{
+				Template: valid,
+				Match:    test.mode,
+				Now: func() time.Time {
+					return time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+				},
+			}
+			settings.SetDelimiters("", "")
+			settings.SetValues(nil)
+
+			a := goheader.Analyzer{Settings: settings}
+
+			diag, err := a.Analyze(header(t, "/*\n"+test.header+"\n*/"))
+			require.NoError(t, err)
+
+			if test.message == "" {
+				require.Nil(t, diag)
+				return
+			}
+			require.NotNil(t, diag)
+			require.True(t, strings.HasPrefix(diag.Message, test.message), diag.Message)
+		})
+	}
+}
+
+func TestAnalyzer_LineMatcher(t *testing.T) {
+	const template = "Copyright Acme\n\nSee:\n    http://www.apache.org/licenses/LICENSE-2.0"
+
+	testCases := []struct {
+		name    string
+		matcher goheader.MatcherSettings
+		mode    goheader.MatchMode
+		header  string
+		match   bool
+	}{
+		{
+			name:   "regexp tabs",
+			header: "Copyright Acme\n\nSee:\n\thttp://www.apache.org/licenses/LICENSE-2.0",
+		},
+		{
+			name:    "tabs",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher},
+			header:  "Copyright Acme\n\nSee:\n\thttp://www.apache.org/licenses/LICENSE-2.0",
+			match:   true,
+		},
+		{
+			name:    "CRLF and trailing spaces",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher},
+			header:  "Copyright Acme  \r\n\r\nSee:\r\n    http://www.apache.org/licenses/LICENSE-2.0\r",
+			match:   true,
+		},
+		{
+			name:    "exact",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher, Whitespace: goheader.ExactWhitespace},
+			header:  "Copyright Acme\n\nSee:\n\thttp://www.apache.org/licenses/LICENSE-2.0",
+		},
+		{
+			name:    "trim",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher},
+			header:  "Copyright  Acme\n\nSee:\n    http://www.apache.org/licenses/LICENSE-2.0",
+		},
+		{
+			name:    "collapse",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher, Whitespace: goheader.CollapseWhitespace},
+			header:  "Copyright \t Acme\n\nSee:\n    http://www.apache.org/licenses/LICENSE-2.0",
+			match:   true,
+		},
+		{
+			name:    "extra lines",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher},
+			header:  "Hacked by X\nCopyright Acme\n\nSee:\n    http://www.apache.org/licenses/LICENSE-2.0\nmore",
+			match:   true,
+		},
+		{
+			name:    "prefix",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher},
+			mode:    goheader.MatchPrefix,
+			header:  "Hacked by X\nCopyright Acme\n\nSee:\n    http://www.apache.org/licenses/LICENSE-2.0",
+		},
+		{
+			name:    "prefix trailing line",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher},
+			mode:    goheader.MatchPrefix,
+			header:  "Copyright Acme\n\nSee:\n    http://www.apache.org/licenses/LICENSE-2.0\nmore",
+			match:   true,
+		},
+		{
+			name:    "exact trailing line",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher},
+			mode:    goheader.MatchExact,
+			header:  "Copyright Acme\n\nSee:\n    http://www.apache.org/licenses/LICENSE-2.0\nmore",
+		},
+		{
+			name:    "optional line",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher, OptionalLines: []string{"", "See:"}},
+			mode:    goheader.MatchExact,
+			header:  "Copyright Acme\n    http://www.apache.org/licenses/LICENSE-2.0",
+			match:   true,
+		},
+		{
+			name:    "required line",
+			matcher: goheader.MatcherSettings{Type: goheader.LineMatcher, OptionalLines: []string{"See:"}},
+			mode:    goheader.MatchExact,
+			header:  "Copyright Acme\n    http://www.apache.org/licenses/LICENSE-2.0",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			settings := &goheader.Settings{Template: template, Matcher: test.matcher, Match: test.mode}
+			settings.SetDelimiters("", "")
+			settings.SetValues(nil)
+
+			a := goheader.Analyzer{Settings: settings}
+
+			diag, err := a.Analyze(header(t, "/*\n"+test.header+"\n*/"))
+			require.NoError(t, err)
+
+			if test.match {
+				require.Nil(t, diag)
+				return
+			}
+			require.NotNil(t, diag)
+			require.Equal(t, goheader.CategoryTemplateMismatch, diag.Category)
+		})
+	}
+}
+
+func TestAnalyzer_TemplateBlocks(t *testing.T) {
+	const contributors = "Copyright {{ .YEAR }} Acme\n" +
+		"{{ optional }}Modified by {{ .AUTHOR }}{{ end }}\n" +
+		"{{ repeat }}Contributor: {{ .NAME }}{{ end }}\n" +
+		"SPDX-License-Identifier: MIT"
+
+	testCases := []struct {
+		name     string
+		template string
+		header   string
+		match    bool
+		fixed    string
+	}{
+		{
+			name:     "minimal",
+			template: contributors,
+			header:   "Copyright 2026 Acme\nSPDX-License-Identifier: MIT",
+			match:    true,
+		},
+		{
+			name:     "all sections",
+			template: contributors,
+			header:   "Copyright 2026 Acme\nModified by Bob\nContributor: Alice\nContributor: Eve\nSPDX-License-Identifier: MIT",
+			match:    true,
+		},
+		{
+			name:     "optional twice",
+			template: contributors,
+			header:   "Copyright 2026 Acme\nModified by Bob\nModified by Eve\nSPDX-License-Identifier: MIT",
+			fixed:    "Copyright 2026 Acme\nSPDX-License-Identifier: MIT",
+		},
+		{
+			name:     "invalid repeated line",
+			template: contributors,
+			header:   "Copyright 2026 Acme\nContributor: Alice\nContributor: eve\nSPDX-License-Identifier: MIT",
+			fixed:    "Copyright 2026 Acme\nSPDX-License-Identifier: MIT",
+		},
+		{
+			name:     "inline",
+			template: "Copyright {{ optional }}(c) {{ end }}Acme",
+			header:   "Copyright (c) Acme",
+			match:    true,
+		},
+		{
+			name:     "inline missing",
+			template: "Copyright {{ optional }}(c) {{ end }}Acme",
+			header:   "Copyright Acme",
+			match:    true,
+		},
+		{
+			name:     "end on the next line",
+			template: "A\n{{ repeat }}B\nC\n{{ end }}D",
+			header:   "A\nB\nC\nB\nC\nD",
+			match:    true,
+		},
+		{
+			name:     "last line",
+			template: "A\n{{ optional }}B{{ end }}",
+			header:   "A",
+			match:    true,
+		},
+		{
+			name:     "nested",
+			template: "A\n{{ repeat }}B\n{{ optional }}C{{ end }}\n{{ end }}D",
+			header:   "A\nB\nB\nC\nD",
+			match:    true,
+		},
+		{
+			name:     "markers on their own lines",
+			template: "A\n{{ optional }}\nB\n{{ end }}\n{{ repeat }}\nC\n{{ end }}\nD",
+			header:   "A\nB\nC\nC\nD",
+			match:    true,
+		},
+		{
+			name:     "markers on their own lines fix",
+			template: "A\n{{ optional }}\nB\n{{ end }}\n{{ repeat }}\nC\n{{ end }}\nD",
+			header:   "A\nX\nD",
+			fixed:    "A\nD",
+		},
+		{
+			name:     "fix keeps native blocks",
+			template: "A{{ if true }} B{{ end }}\n{{ optional }}C{{ end }}\nD",
+			header:   "X",
+			fixed:    "A B\nD",
+		},
+	}
+
+	for _, matcher := range []goheader.MatcherType{goheader.RegexpMatcher, goheader.LineMatcher} {
+		for _, test := range testCases {
+			t.Run(string(matcher)+" "+test.name, func(t *testing.T) {
+				settings := &goheader.Settings{
+					Template: test.template,
+					Matcher:  goheader.MatcherSettings{Type: matcher},
+					Match:    goheader.MatchExact,
+					Now: func() time.Time {
+						return time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+					},
+				}
+				settings.SetDelimiters("", "")
+				settings.SetValues(map[string]string{"AUTHOR": ".+", "NAME": "[A-Z][a-z]+"})
+
+				a := goheader.Analyzer{Settings: settings}
+
+				diag, err := a.Analyze(header(t, "/*\n"+test.header+"\n*/"))
+				require.NoError(t, err)
+
+				if test.match {
+					require.Nil(t, diag)
+					return
+				}
+				require.NotNil(t, diag)
+				require.Equal(t, "/*\n"+test.fixed+"\n*/\n", string(diag.SuggestedFixes[0].TextEdits[0].NewText))
+			})
+		}
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		for template, message := range map[string]string{
+			"A\n{{ optional }}B":            "{{ optional }} block is not closed",
+			"{{ repeat 2 }}B{{ end }}":      "{{ repeat }} doesn't have arguments",
+			"A {{ optional }}B\nC{{ end }}": "optional block must be in one line or contain whole lines",
+			"A{{- optional }}B{{ end }}":    "{{ optional }} doesn't support trim markers",
+			"A{{ repeat }}B{{ end -}}":      "{{ end }} of {{ repeat }} doesn't support trim markers",
+		} {
+			settings := &goheader.Settings{Template: template, Matcher: goheader.MatcherSettings{Type: goheader.LineMatcher}}
+			settings.SetDelimiters("", "")
+			settings.SetValues(nil)
+
+			a := goheader.Analyzer{Settings: settings}
+
+			_, err := a.Analyze(header(t, "/*\nA\n*/"))
+			require.EqualError(t, err, message, template)
+		}
+	})
+}
+
+func TestAnalyzer_ListValue(t *testing.T) {
+	settings := &goheader.Settings{Template: "Copyright {{ .HOLDER }}"}
+	settings.SetDelimiters("", "")
+	settings.SetValues(nil)
+	settings.Values["HOLDER"] = &goheader.ListValue{Values: []string{"Acme Inc.", "Acme (EU)"}}
+
+	a := goheader.Analyzer{Settings: settings}
+
+	for _, holder := range []string{"Acme Inc.", "Acme (EU)"} {
+		diag, err := a.Analyze(header(t, "/*\nCopyright "+holder+"\n*/"))
+		require.NoError(t, err)
+		require.Nil(t, diag, holder)
+	}
+
+	diag, err := a.Analyze(header(t, "/*\nCopyright Acme IncX\n*/"))
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+	require.Equal(t, "/*\nCopyright Acme Inc.\n*/\n", string(diag.SuggestedFixes[0].TextEdits[0].NewText))
+
+	settings.Values["HOLDER"] = &goheader.ListValue{Values: []string{"Acme Inc.", "Acme (EU)"}, Fix: "Acme (EU)"}
+
+	diag, err = a.Analyze(header(t, "/*\nCopyright Acme EU\n*/"))
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+	require.Equal(t, "/*\nCopyright Acme (EU)\n*/\n", string(diag.SuggestedFixes[0].TextEdits[0].NewText))
+}
+
+func TestConfig_ListVar(t *testing.T) {
+	parse := func(t *testing.T, vars string) (*goheader.Settings, error) {
+		t.Helper()
+
+		configPath := filepath.Join(t.TempDir(), "config.yml")
+		content := "template: Copyright {{ .HOLDER }}\nnow: 2026-03-01\nvars:\n" + vars
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0o600))
+
+		cfg, err := goheader.Parse(configPath)
+		if err != nil {
+			return nil, err
+		}
+
+		settings := &goheader.Settings{}
+		return settings, cfg.FillSettings(settings)
+	}
+
+	fix := func(t *testing.T, settings *goheader.Settings, text string) string {
+		t.Helper()
+
+		a := goheader.Analyzer{Settings: settings}
+		diag, err := a.Analyze(header(t, "// "+text))
+		require.NoError(t, err)
+		require.NotNil(t, diag)
+		return string(diag.SuggestedFixes[0].TextEdits[0].NewText)
+	}
+
+	t.Run("fix refers to values", func(t *testing.T) {
+		settings, err := parse(t, "  HOLDER:\n    values: [2026 Acme, Acme]\n    fix: \"{{ .YEAR }} Acme\"\n")
+		require.NoError(t, err)
+		require.Equal(t, "// Copyright 2026 Acme\n", fix(t, settings, "Copyright Other"))
+	})
+
+	t.Run("keep", func(t *testing.T) {
+		settings, err := parse(t, "  HOLDER:\n    values: [Acme]\n    keep: true\n")
+		require.NoError(t, err)
+		require.Equal(t, "// Copyright Other\n", fix(t, settings, "Copyright Other"))
+	})
+
+	t.Run("fix out of the list", func(t *testing.T) {
+		_, err := parse(t, "  HOLDER:\n    values: [Acme Inc., Acme GmbH]\n    fix: Acme Ltd\n")
+		require.EqualError(t, err, `fix "Acme Ltd" of value HOLDER doesn't match "(?:Acme Inc\\.|Acme GmbH)"`)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := parse(t, "  HOLDER: []\n")
+		require.ErrorContains(t, err, "list of values must not be empty")
+
+		_, err = parse(t, "  HOLDER:\n    values: []\n")
+		require.ErrorContains(t, err, "list of values must not be empty")
+	})
+}
+
+func TestAnalyzer_ChangedValuesShouldNotBeCached(t *testing.T) {
+	settings := &goheader.Settings{Template: "Copyright {{ .HOLDER }}"}
+	settings.SetDelimiters("", "")
+	settings.SetValues(map[string]string{"HOLDER": "Acme"})
+
+	a := goheader.Analyzer{Settings: settings}
+
+	diag, err := a.Analyze(header(t, "// Copyright Acme"))
+	require.NoError(t, err)
+	require.Nil(t, diag)
+
+	settings.Values["HOLDER"].(*goheader.RegexpValue).RawValue = "Other"
+
+	diag, err = a.Analyze(header(t, "// Copyright Acme"))
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+
+	settings.Values["HOLDER"] = &goheader.ConstValue{RawValue: "Acme"}
+
+	diag, err = a.Analyze(header(t, "// Copyright Acme"))
+	require.NoError(t, err)
+	require.Nil(t, diag)
+}
+
+func TestAnalyzer_YearRangePolicy(t *testing.T) {
+	testCases := []struct {
+		policy   goheader.YearRangePolicy
+		header   string
+		expected string
+	}{
+		{policy: goheader.ExtendYearRange, header: "2015-2024", expected: "2015-2026"},
+		{policy: goheader.ExtendYearRange, header: "2015", expected: "2015-2026"},
+		{policy: goheader.ReplaceYearRange, header: "2015-2024", expected: "2026"},
+		{policy: goheader.FirstYearOnly, header: "2015-2024", expected: "2015"},
+		{policy: goheader.YearList, header: "2015, 2019", expected: "2015, 2019, 2026"},
+		{policy: goheader.YearList, header: "2015-2024", expected: "2015-2024, 2026"},
+	}
+
+	for _, test := range testCases {
+		t.Run(string(test.policy)+"/"+test.header, func(t *testing.T) {
+			settings := &goheader.Settings{YearRangePolicy: test.policy}
+			settings.SetDelimiters("", "")
+			settings.SetValues(map[string]string{"YEAR": "2026"})
+			settings.Template = "Copyright {{ .YEAR_RANGE }} Acme\nSPDX-License-Identifier: MIT"
+
+			a := goheader.Analyzer{Settings: settings}
+
+			diag, err := a.Analyze(header(t, "/*\nCopyright "+test.header+" Acme\nSPDX-License-Identifier: Apache-2.0\n*/"))
+			require.NoError(t, err)
+			require.NotNil(t, diag)
+			require.Len(t, diag.SuggestedFixes, 1)
+
+			expected := "/*\nCopyright " + test.expected + " Acme\nSPDX-License-Identifier: MIT\n*/\n"
+			require.Equal(t, expected, string(diag.SuggestedFixes[0].TextEdits[0].NewText))
+		})
+	}
+}
+
+// gitModYear returns the mod year the same way as checking each file separately with git.
+func gitModYear(t *testing.T, path string) string {
+	diff, err := exec.Command("git", "diff", path).CombinedOutput()
+	if err == nil && len(diff) == 0 {
+		line, err := exec.Command("git", "log", "-1", "--pretty=format:%cd", "--date=iso", "--", path).CombinedOutput()
+		if err == nil {
+			modTime, err := time.Parse("2006-01-02 15:04:05 -0700", string(line))
+			if err != nil {
+				return fmt.Sprint(time.Now().Year())
+			}
+			return fmt.Sprint(modTime.Year())
+		}
+	}
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	return fmt.Sprint(info.ModTime().Year())
+}
+
+func TestAnalyzer_ModYearShouldMatchGit(t *testing.T) {
+	settings := &goheader.Settings{Template: "{{ .MOD_YEAR }}"}
+	settings.SetDelimiters("", "")
+	settings.SetValues(nil)
+
+	a := goheader.Analyzer{Settings: settings}
+
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	untracked := filepath.Join(t.TempDir(), "untracked.go")
+	require.NoError(t, os.WriteFile(untracked, []byte("package untracked"), 0o600))
+
+	for _, path := range append(files, "testdata/src/cgo/cgo.go", untracked) {
+		_, file := header(t, "/*"+gitModYear(t, path)+"*/")
+
+		diag, err := a.Analyze(path, file)
+		require.NoError(t, err)
+		require.Nil(t, diag, path)
+	}
+}
+
+func gitCommit(t *testing.T, dir, date string) {
+	t.Helper()
+
+	gitCommitAuthored(t, dir, date, date)
+}
+
+func gitCommitAuthored(t *testing.T, dir, committerDate, authorDate string) {
 	t.Helper()
 
-	fs := token.NewFileSet()
-	tokenFile, err := parser.ParseFile(fs, filename, nil, parser.ParseComments)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@test", "commit", "-q", "-m", "test"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+committerDate, "GIT_AUTHOR_DATE="+authorDate)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+}
+
+func TestAnalyzer_ModYearShouldUseRepositoryOfFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "nested")
+	require.NoError(t, os.MkdirAll(nested, 0o700))
+
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "nested.go"), []byte("package nested"), 0o600))
+	gitCommit(t, nested, "2018-05-01T10:00:00Z")
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "root.go"), []byte("package root"), 0o600))
+	gitCommit(t, root, "2019-05-01T10:00:00Z")
+
+	var mu sync.Mutex
+	var sources = make(map[string]goheader.ModTimeSource)
+
+	settings := &goheader.Settings{
+		Template: "{{ .MOD_YEAR }}",
+		ModTimeReporter: func(path string, _ time.Time, source goheader.ModTimeSource) {
+			mu.Lock()
+			defer mu.Unlock()
+			sources[path] = source
+		},
+	}
+	settings.SetDelimiters("", "")
+	settings.SetValues(nil)
+
+	a := goheader.Analyzer{Settings: settings}
+
+	for path, year := range map[string]string{
+		filepath.Join(root, "root.go"):       "2019",
+		filepath.Join(nested, "nested.go"):   "2018",
+		filepath.Join(t.TempDir(), "tmp.go"): fmt.Sprint(time.Now().Year()),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			require.NoError(t, os.WriteFile(path, []byte("package tmp"), 0o600))
+		}
+
+		_, file := header(t, "/*"+year+"*/")
+
+		diag, err := a.Analyze(path, file)
+		require.NoError(t, err)
+		require.Nil(t, diag, path)
+	}
+
+	require.Equal(t, goheader.ModTimeFromGit, sources[filepath.Join(root, "root.go")])
+	require.Equal(t, goheader.ModTimeFromGit, sources[filepath.Join(nested, "nested.go")])
+	require.Len(t, sources, 3)
+
+	for path, source := range sources {
+		if strings.HasSuffix(path, "tmp.go") {
+			require.Equal(t, goheader.ModTimeFromFileSystem, source)
+		}
+	}
+}
+
+func TestAnalyzer_CreatedYearShouldFollowRenames(t *testing.T) {
+	root := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.go"), []byte("package a\n\nfunc A() {}\n"), 0o600))
+	gitCommitAuthored(t, root, "2015-05-01T10:00:00Z", "2014-05-01T10:00:00Z")
+
+	require.NoError(t, os.Rename(filepath.Join(root, "a.go"), filepath.Join(root, "b.go")))
+	gitCommitAuthored(t, root, "2019-05-01T10:00:00Z", "2019-05-01T10:00:00Z")
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.go"), []byte("package a\n\nfunc A() {}\n\nfunc B() {}\n"), 0o600))
+	gitCommitAuthored(t, root, "2021-05-01T10:00:00Z", "2020-05-01T10:00:00Z")
+
+	for source, expected := range map[goheader.DateSource]string{
+		goheader.CommitterDate: "2015-2021",
+		goheader.AuthorDate:    "2014-2020",
+	} {
+		t.Run(string(source), func(t *testing.T) {
+			settings := &goheader.Settings{
+				Template:   "{{ .CREATED_YEAR }}-{{ .MOD_YEAR }}",
+				DateSource: source,
+			}
+			settings.SetDelimiters("", "")
+			settings.SetValues(nil)
+
+			a := goheader.Analyzer{Settings: settings}
+
+			_, file := header(t, "/*"+expected+"*/")
+
+			diag, err := a.Analyze(filepath.Join(root, "b.go"), file)
+			require.NoError(t, err)
+			require.Nil(t, diag)
+		})
+	}
+}
+
+func git(t *testing.T, dir, date string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@test"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+date, "GIT_AUTHOR_DATE="+date)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestAnalyzer_ModYearShouldFollowMerges(t *testing.T) {
+	root := t.TempDir()
+
+	write := func(name, text string) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte("package a\n\n"+text), 0o600))
+	}
+
+	write("a.go", "// base\n")
+	write("b.go", "// base\n")
+	write("c.go", "// base\n")
+	gitCommit(t, root, "2015-05-01T10:00:00Z")
+	git(t, root, "2015-05-01T10:00:00Z", "branch", "-M", "main")
+
+	git(t, root, "2016-05-01T10:00:00Z", "checkout", "-q", "-b", "side")
+	write("a.go", "// side\n")
+	write("b.go", "// side\n")
+	git(t, root, "2016-05-01T10:00:00Z", "commit", "-q", "-am", "side")
+
+	git(t, root, "2017-05-01T10:00:00Z", "checkout", "-q", "main")
+	write("a.go", "// main\n")
+	git(t, root, "2017-05-01T10:00:00Z", "commit", "-q", "-am", "main")
+
+	// The conflict is resolved with a text that differs from both parents.
+	cmd := exec.Command("git", "-c", "user.name=test", "-c", "user.email=test@test", "merge", "-q", "side")
+	cmd.Dir = root
+	out, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	require.Contains(t, string(out), "CONFLICT")
+	write("a.go", "// merged\n")
+	git(t, root, "2018-05-01T10:00:00Z", "commit", "-q", "-am", "merge")
+
+	// The change of the side branch is discarded by the merge.
+	git(t, root, "2019-05-01T10:00:00Z", "checkout", "-q", "side")
+	write("b.go", "// discarded\n")
+	git(t, root, "2019-05-01T10:00:00Z", "commit", "-q", "-am", "discarded")
+	git(t, root, "2020-05-01T10:00:00Z", "checkout", "-q", "main")
+	git(t, root, "2020-05-01T10:00:00Z", "merge", "-q", "-s", "ours", "side")
+
+	settings := &goheader.Settings{Template: "{{ .MOD_YEAR }}"}
+	settings.SetDelimiters("", "")
+	settings.SetValues(nil)
+
+	a := goheader.Analyzer{Settings: settings}
+
+	for name, year := range map[string]string{
+		"a.go": "2018",
+		"b.go": "2016",
+		"c.go": "2015",
+	} {
+		_, file := header(t, "/*"+year+"*/")
+
+		diag, err := a.Analyze(filepath.Join(root, name), file)
+		require.NoError(t, err)
+		require.Nil(t, diag, name)
+	}
+}
+
+func TestAnalyzer_ReferenceTime(t *testing.T) {
+	check := func(t *testing.T, settings *goheader.Settings, year string) {
+		t.Helper()
+
+		a := goheader.Analyzer{Settings: settings}
+
+		diag, err := a.Analyze(header(t, "/*"+year+"*/"))
+		require.NoError(t, err)
+		require.Nil(t, diag)
+	}
+
+	t.Run("config", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "1262304000")
+
+		cfg := goheader.Config{Template: "{{ .YEAR }}", Now: "2025-06-01"}
+		settings := &goheader.Settings{}
+		require.NoError(t, cfg.FillSettings(settings))
+
+		check(t, settings, "2025")
+	})
+
+	t.Run("SOURCE_DATE_EPOCH", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "1262304000")
+
+		cfg := goheader.Config{Template: "{{ .YEAR_RANGE }}"}
+		settings := &goheader.Settings{}
+		require.NoError(t, cfg.FillSettings(settings))
+
+		check(t, settings, "2005-2010")
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		settings := &goheader.Settings{
+			Template: "{{ .YEAR }}",
+			Now: func() time.Time {
+				return time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+			},
+		}
+		settings.SetDelimiters("", "")
+		settings.SetValues(nil)
+
+		check(t, settings, "2021")
+	})
+
+	t.Run("settings after values", func(t *testing.T) {
+		settings := &goheader.Settings{Template: "{{ .YEAR }} {{ .MOD_YEAR }}"}
+		settings.SetDelimiters("", "")
+		settings.SetValues(nil)
+
+		a := goheader.Analyzer{Settings: settings}
+		check(t, settings, fmt.Sprintf("%v %v", time.Now().Year(), time.Now().Year()))
+
+		settings.Now = func() time.Time {
+			return time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+		}
+
+		diag, err := a.Analyze(header(t, "/*2021 2021*/"))
+		require.NoError(t, err)
+		require.Nil(t, diag)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cfg := goheader.Config{Template: "{{ .YEAR }}", Now: "yesterday"}
+		require.Error(t, cfg.FillSettings(&goheader.Settings{}))
+	})
+}
+
+type errorRecorder struct {
+	errors []string
+}
+
+func (r *errorRecorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAnalyzer_ErrorsShouldNotStopChecking(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	cfg, err := goheader.Parse(filepath.Join(testdata, "src", "brokenvar", "brokenvar.yml"))
+	require.NoError(t, err)
+
+	cfg.Parallel = 1
+
+	settings := &goheader.Settings{}
+	require.NoError(t, cfg.FillSettings(settings))
+
+	var recorder errorRecorder
+	results := analysistest.Run(&recorder, testdata, goheader.New(settings), "brokenvar")
+	require.Len(t, results, 1)
+
+	require.Error(t, results[0].Err)
+	require.Equal(t, 1, strings.Count(results[0].Err.Error(), "missing closing )"), results[0].Err.Error())
+
+	require.Len(t, results[0].Diagnostics, 1)
+	require.Equal(t, `template doesn't match: expected "A 2020", found "A 2021"`, results[0].Diagnostics[0].Message)
+}
+
+func TestAnalyzer_Categories(t *testing.T) {
+	settings := &goheader.Settings{}
+	settings.SetDelimiters("", "")
+	settings.SetValues(nil)
+	settings.Template = "Copyright Acme"
+	settings.CommentStyle = goheader.LineComment
+
+	a := goheader.Analyzer{Settings: settings}
+
+	diag, err := a.Analyze(header(t, "// Copyright Other"))
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+	require.Equal(t, goheader.CategoryTemplateMismatch, diag.Category)
+
+	diag, err = a.Analyze(header(t, "/*\nCopyright Acme\n*/"))
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+	require.Equal(t, goheader.CategoryWrongCommentStyle, diag.Category)
+
+	settings.Categories = map[string]goheader.CategorySettings{
+		goheader.CategoryTemplateMismatch:  {Disabled: true},
+		goheader.CategoryWrongCommentStyle: {Severity: goheader.SeverityWarning},
+	}
+
+	diag, err = a.Analyze(header(t, "// Copyright Other"))
+	require.NoError(t, err)
+	require.Nil(t, diag)
+
+	diag, err = a.Analyze(header(t, "/*\nCopyright Acme\n*/"))
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+	require.Equal(t, goheader.SeverityWarning, settings.Category(diag.Category).Severity)
+	require.Equal(t, goheader.SeverityError, settings.Category(goheader.CategoryMissingHeader).Severity)
+}
+
+func TestConfig_Categories(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "template: Copyright Acme\ncategories:\n  missing-header:\n    enabled: false\n  config-error:\n    severity: warning\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	cfg, err := goheader.Parse(path)
+	require.NoError(t, err)
+
+	settings := &goheader.Settings{}
+	require.NoError(t, cfg.FillSettings(settings))
+	require.True(t, settings.Category(goheader.CategoryMissingHeader).Disabled)
+	require.Equal(t, goheader.SeverityWarning, settings.Category(goheader.CategoryConfigError).Severity)
+	require.Equal(t, goheader.SeverityError, settings.Category(goheader.CategoryOutdatedYear).Severity)
+
+	require.Equal(t, goheader.RegexpMatcher, settings.Matcher.Type)
+
+	cfg.Categories = map[string]goheader.CategoryConfig{"unknown": {}}
+	require.EqualError(t, cfg.FillSettings(&goheader.Settings{}), `unknown diagnostic category "unknown"`)
+
+	cfg.Categories = map[string]goheader.CategoryConfig{goheader.CategoryConfigError: {Severity: "fatal"}}
+	require.EqualError(t, cfg.FillSettings(&goheader.Settings{}), `unknown severity "fatal"`)
+}
+
+func TestConfig_Matcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "template: Copyright Acme\nmatch: exact\nmatcher:\n  type: lines\n  whitespace: collapse\n  optional-lines: [\"Modified by {{ .AUTHOR }}\"]\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	cfg, err := goheader.Parse(path)
+	require.NoError(t, err)
+
+	settings := &goheader.Settings{}
+	require.NoError(t, cfg.FillSettings(settings))
+	require.Equal(t, goheader.MatcherSettings{
+		Type:          goheader.LineMatcher,
+		Whitespace:    goheader.CollapseWhitespace,
+		OptionalLines: []string{"Modified by {{ .AUTHOR }}"},
+	}, settings.Matcher)
+	require.Equal(t, goheader.MatchExact, settings.Match)
+
+	cfg.Match = "anywhere"
+	require.EqualError(t, cfg.FillSettings(&goheader.Settings{}), `unknown match mode "anywhere"`)
+
+	cfg.Match = ""
+	cfg.Matcher.Type = "fuzzy"
+	require.EqualError(t, cfg.FillSettings(&goheader.Settings{}), `unknown matcher type "fuzzy"`)
+}
+
+func TestConfig_OptionalLinesShouldBeMigrated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "template: |-\n  Copyright Acme\n  Modified by {{.AUTHOR}}\n  SPDX-License-Identifier: MIT\n" +
+		"vars:\n  AUTHOR: \"[A-Z][a-z]+\"\n" +
+		"matcher:\n  type: lines\n  optional-lines: [\"Modified by {{.AUTHOR}}\", \"See {{ url }}\"]\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	cfg, err := goheader.Parse(path)
+	require.NoError(t, err)
+
+	settings := &goheader.Settings{}
+	require.NoError(t, cfg.FillSettings(settings))
+	require.Equal(t, []string{"Modified by {{ .AUTHOR }}", "See {{ .url }}"}, settings.Matcher.OptionalLines)
+
+	a := goheader.Analyzer{Settings: settings}
+
+	diag, err := a.Analyze(header(t, "// Copyright Acme\n// SPDX-License-Identifier: MIT"))
+	require.NoError(t, err)
+	require.Nil(t, diag)
+}
+
+func TestConfig_TrimMarkersShouldBeMigrated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "template: \"Copyright {{- YEAR }} / {{ AUTHOR -}} {{- optional }}.{{ end }}\"\nvars:\n  AUTHOR: \"[A-Z][a-z]+\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	cfg, err := goheader.Parse(path)
+	require.NoError(t, err)
+
+	settings := &goheader.Settings{}
+	require.NoError(t, cfg.FillSettings(settings))
+	require.Equal(t, "Copyright {{- .YEAR }} / {{ .AUTHOR -}} {{- optional }}.{{ end }}", settings.Template)
+
+	a := goheader.Analyzer{Settings: settings}
+
+	_, err = a.Analyze(header(t, "// Copyright2026 / Alice."))
+	require.EqualError(t, err, "{{ optional }} doesn't support trim markers")
+}
+
+func TestAnalyzer_ConfigErrorsCanBeReported(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	cfg, err := goheader.Parse(filepath.Join(testdata, "src", "brokenvar", "brokenvar.yml"))
 	require.NoError(t, err)
 
-	var header string
-	for _, comment := range tokenFile.Comments[0].List {
-		header += comment.Text + "\n"
+	cfg.Parallel = 1
+
+	settings := &goheader.Settings{}
+	require.NoError(t, cfg.FillSettings(settings))
+	settings.Categories = map[string]goheader.CategorySettings{
+		goheader.CategoryConfigError: {Severity: goheader.SeverityWarning},
+	}
+
+	var recorder errorRecorder
+	results := analysistest.Run(&recorder, testdata, goheader.New(settings), "brokenvar")
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+
+	var categories []string
+	for _, diag := range results[0].Diagnostics {
+		categories = append(categories, diag.Category)
+		// The severity is shown in the message, analysis drivers don't have severities.
+		require.Equal(t, diag.Category == goheader.CategoryConfigError, strings.HasPrefix(diag.Message, "warning: "), diag.Message)
+	}
+	// Each file with a broken template gets its own diagnostic.
+	require.ElementsMatch(t, []string{goheader.CategoryConfigError, goheader.CategoryConfigError, goheader.CategoryTemplateMismatch}, categories)
+}
+
+func TestSettings_GetRule(t *testing.T) {
+	settings := &goheader.Settings{
+		Rules: []goheader.Rule{
+			{Paths: []string{"third_party/**"}},
+			{Paths: []string{"third_party/foo/**"}},
+			{Paths: []string{"*.pb.go"}},
+			{Paths: []string{"cmd/*/main.go"}},
+		},
+	}
+
+	testCases := []struct {
+		path     string
+		expected int
+	}{
+		{path: "third_party/a.go", expected: 0},
+		{path: "third_party/bar/baz/a.go", expected: 0},
+		{path: "third_party/foo/a.go", expected: 1},
+		{path: "api/v1/api.pb.go", expected: 2},
+		{path: "cmd/tool/main.go", expected: 3},
+		{path: "cmd/tool/sub/main.go", expected: -1},
+		{path: "internal/third_party/a.go", expected: -1},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.path, func(t *testing.T) {
+			rule := settings.GetRule(test.path)
+			if test.expected < 0 {
+				require.Nil(t, rule)
+				return
+			}
+			require.Same(t, &settings.Rules[test.expected], rule)
+		})
+	}
+}
+
+func TestConfig_FixShouldMatchValue(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	content := "template: Copyright {{ .COMPANY }}\nvars:\n  COMPANY:\n    value: sales|product\n    fix: marketing\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0o600))
+
+	cfg, err := goheader.Parse(configPath)
+	require.NoError(t, err)
+	require.EqualError(t, cfg.FillSettings(&goheader.Settings{}), `fix "marketing" of value COMPANY doesn't match "sales|product"`)
+
+	cfg.Vars["COMPANY"] = goheader.Var{Value: "{{ .YEAR }} (sales|product)", Fix: "{{ .YEAR }} sales"}
+	require.NoError(t, cfg.FillSettings(&goheader.Settings{}))
+}
+
+func TestConfig_RulesShouldRejectBadPatterns(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	content := "template: Copyright Acme\nrules:\n  - paths: [\"*.pb.go\"]\n    skip: true\n  - paths: [\"cmd/**\", \"cmd/[a.go\"]\n    skip: true\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0o600))
+
+	cfg, err := goheader.Parse(configPath)
+	require.NoError(t, err)
+
+	err = cfg.FillSettings(&goheader.Settings{})
+	require.ErrorIs(t, err, path.ErrBadPattern)
+	require.EqualError(t, err, `rule 1: path "cmd/[a.go": syntax error in pattern`)
+}
+
+func applyFix(fset *token.FileSet, src string, fix analysis.SuggestedFix) string {
+	var result string
+	var last int
+
+	for _, edit := range fix.TextEdits {
+		start, end := fset.Position(edit.Pos).Offset, fset.Position(edit.End).Offset
+		result += src[last:start] + string(edit.NewText)
+		last = end
 	}
 
-	return header
+	return result + src[last:]
 }
 
 func header(t *testing.T, header string) (string, *ast.File) {
@@ -181,6 +1625,6 @@ func header(t *testing.T, header string) (string, *ast.File) {
 			},
 		},
 
-		Package: token.Pos(len(header)),
+		Package: token.Pos(len(header) + 2),
 	}
 }
@@ -0,0 +1,218 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of template blocks. Blocks are closed by `end` like `if` and `range` actions.
+const (
+	// optionalBlock is `{{ optional }}...{{ end }}`, the section can be missing in headers.
+	optionalBlock = "optional"
+	// repeatBlock is `{{ repeat }}...{{ end }}`, the section can be repeated any number of times or be missing.
+	repeatBlock = "repeat"
+)
+
+// block is a section of a template. open and close are ranges of its actions.
+type block struct {
+	kind        string
+	open, close [2]int
+}
+
+// section is a block prepared for rewriting.
+type section struct {
+	kind string
+	// text is the content of the block with rewritten nested blocks.
+	text string
+	// lines is set if the block contains whole lines. Such blocks include the line break before
+	// or after the lines, so a missing section doesn't leave an empty line.
+	lines         bool
+	before, after string
+	// raw is the block with its actions.
+	raw string
+	// breaks is the number of line breaks of the template replaced by the section besides before and after.
+	breaks int
+}
+
+// blocks finds optional and repeat blocks of the template, nested blocks follow the outer ones.
+func (a *Analyzer) blocks(text string) ([]block, error) {
+	left, right := a.Settings.LeftDelim, a.Settings.RightDelim
+
+	var result []block
+	// stack contains indexes of open blocks, -1 is used for other actions closed by end.
+	var stack []int
+
+	for i := 0; ; {
+		start := strings.Index(text[i:], left)
+		if start < 0 {
+			break
+		}
+		start += i
+
+		end := strings.Index(text[start+len(left):], right)
+		if end < 0 {
+			break
+		}
+		end += start + len(left) + len(right)
+		i = end
+
+		raw := text[start+len(left) : end-len(right)]
+		action := strings.Trim(raw, " \t-")
+		keyword, _, _ := strings.Cut(action, " ")
+		// Blocks are not executed by text/template, so trimming of spaces around them is not supported.
+		trimmed := strings.HasPrefix(raw, "-") || strings.HasSuffix(raw, "-")
+
+		switch keyword {
+		case optionalBlock, repeatBlock:
+			if action != keyword {
+				return nil, fmt.Errorf("%v %v %v doesn't have arguments", left, keyword, right)
+			}
+			if trimmed {
+				return nil, fmt.Errorf("%v %v %v doesn't support trim markers", left, keyword, right)
+			}
+			stack = append(stack, len(result))
+			result = append(result, block{kind: keyword, open: [2]int{start, end}})
+		case "if", "range", "with", "block", "define":
+			stack = append(stack, -1)
+		case "end":
+			if len(stack) == 0 {
+				continue
+			}
+			if index := stack[len(stack)-1]; index >= 0 {
+				if trimmed {
+					return nil, fmt.Errorf("%v end %v of %v %v %v doesn't support trim markers", left, right, left, result[index].kind, right)
+				}
+				result[index].close = [2]int{start, end}
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	for _, index := range stack {
+		if index >= 0 {
+			return nil, fmt.Errorf("%v %v %v block is not closed", left, result[index].kind, right)
+		}
+	}
+
+	return result, nil
+}
+
+// rewriteBlocks replaces blocks of the template with results of replace. Nested blocks are replaced first.
+func (a *Analyzer) rewriteBlocks(text string, replace func(s section) (string, error)) (string, error) {
+	blocks, err := a.blocks(text)
+	if err != nil || len(blocks) == 0 {
+		return text, err
+	}
+
+	return rewriteRange(text, 0, len(text), true, blocks, replace)
+}
+
+// rewriteRange rewrites blocks of text[start:end]. bounded reports whether start and end are line boundaries.
+func rewriteRange(text string, start, end int, bounded bool, blocks []block, replace func(s section) (string, error)) (string, error) {
+	var sb strings.Builder
+	var pos = start
+
+	for len(blocks) > 0 {
+		b := blocks[0]
+
+		n := 1
+		for n < len(blocks) && blocks[n].open[0] < b.close[0] {
+			n++
+		}
+		nested := blocks[1:n]
+		blocks = blocks[n:]
+
+		contentStart, contentEnd := b.open[1], b.close[0]
+		trailingBreak := contentEnd > contentStart && text[contentEnd-1] == '\n'
+
+		lineStart := b.open[0] == start && bounded || b.open[0] > 0 && text[b.open[0]-1] == '\n'
+		closeLineEnd := b.close[1] == end && bounded || b.close[1] < len(text) && text[b.close[1]] == '\n'
+		lineEnd := trailingBreak || closeLineEnd
+
+		s := section{kind: b.kind, lines: lineStart && lineEnd}
+
+		// Markers on their own lines are written like lines of the content, so both line breaks
+		// of the content belong to the markers.
+		ownLines := s.lines && trailingBreak && closeLineEnd && contentEnd-1 > contentStart && text[contentStart] == '\n'
+		if ownLines {
+			contentStart++
+			trailingBreak = false
+		}
+
+		if s.lines && (trailingBreak || ownLines) {
+			contentEnd--
+		}
+
+		inner, err := rewriteRange(text, contentStart, contentEnd, s.lines, nested, replace)
+		if err != nil {
+			return "", err
+		}
+		s.text = inner
+		s.raw = text[b.open[0]:contentStart] + inner + text[contentEnd:b.close[1]]
+
+		from, to := b.open[0], b.close[1]
+		var suffix string
+
+		if s.lines {
+			switch {
+			case from > pos:
+				// The line break before the block is already written if pos is after it.
+				from--
+				s.before = "\n"
+				if trailingBreak {
+					suffix = "\n"
+				}
+			case trailingBreak:
+				s.after = "\n"
+			case to < end:
+				to++
+				s.after = "\n"
+			}
+		}
+
+		s.breaks = strings.Count(text[b.open[0]:b.close[1]], "\n") - strings.Count(suffix, "\n")
+
+		replaced, err := replace(s)
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(text[pos:from])
+		sb.WriteString(replaced)
+		sb.WriteString(suffix)
+		pos = to
+	}
+
+	sb.WriteString(text[pos:end])
+
+	return sb.String(), nil
+}
+
+// regexpSection renders the block as a regexp group.
+func regexpSection(s section) (string, error) {
+	if s.kind == repeatBlock {
+		return "(?:" + s.before + s.text + s.after + ")*", nil
+	}
+	return "(?:" + s.before + s.text + s.after + ")?", nil
+}
+
+// dropSection removes the block, so fixes contain the minimal form of the template.
+func dropSection(section) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// matcherCache caches calculated values and compiled templates. Only year values differ between files,
+// so files with the same years share them.
+type matcherCache struct {
+	mu       sync.Mutex
+	values   map[valuesKey]map[string]Value
+	exps     map[expKey]*regexp.Regexp
+	captures map[expKey]*captureExp
+}
+
+// captureExp is a regexp capturing values, names are names of values by indexes of groups, see capturer.
+type captureExp struct {
+	exp   *regexp.Regexp
+	names []string
+}
+
+// valuesKey identifies values of a file.
+type valuesKey struct {
+	// values is the content of configured values the file values are calculated from, see valuesFingerprint.
+	values string
+	// year is the year of Settings.Now, it is used if values don't override YEAR.
+	year string
+	// modYear and createdYear are empty if unknown.
+	modYear, createdYear string
+	policy               YearRangePolicy
+}
+
+type expKey struct {
+	values valuesKey
+	text   string
+	// kind distinguishes regexps compiled from the same text, e.g. anchored ones.
+	kind string
+}
+
+// targetValues are values calculated for a file. Files with the same key share them
+// and regexps compiled with them.
+type targetValues struct {
+	key  valuesKey
+	vars map[string]Value
+}
+
+// valuesFingerprint describes configured values by their content, so changed values are never
+// mixed up with cached ones.
+func valuesFingerprint(values map[string]Value) string {
+	var sb strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		fmt.Fprintf(&sb, "%q:%#v;", name, values[name])
+	}
+	return sb.String()
+}
+
+// getValues returns cached values for the key or calculates them.
+func (c *matcherCache) getValues(key valuesKey, calculate func() (map[string]Value, error)) (map[string]Value, error) {
+	c.mu.Lock()
+	vars, ok := c.values[key]
+	c.mu.Unlock()
+
+	if ok {
+		return vars, nil
+	}
+
+	// Values are calculated without the lock, a concurrent result for the same key is as good.
+	vars, err := calculate()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.values == nil {
+		c.values = make(map[valuesKey]map[string]Value)
+	}
+	if cached, ok := c.values[key]; ok {
+		return cached, nil
+	}
+	c.values[key] = vars
+
+	return vars, nil
+}
+
+// getExp returns the cached regexp for the text compiled with the values or compiles it.
+func (c *matcherCache) getExp(text, kind string, vals targetValues, compile func() (*regexp.Regexp, error)) (*regexp.Regexp, error) {
+	key := expKey{values: vals.key, text: text, kind: kind}
+
+	c.mu.Lock()
+	exp, ok := c.exps[key]
+	c.mu.Unlock()
+
+	if ok {
+		return exp, nil
+	}
+
+	exp, err := compile()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.exps == nil {
+		c.exps = make(map[expKey]*regexp.Regexp)
+	}
+	c.exps[key] = exp
+
+	return exp, nil
+}
+
+// getCapture returns the cached capturing regexp for the text compiled with the values or compiles it.
+func (c *matcherCache) getCapture(text, kind string, vals targetValues, compile func() (*captureExp, error)) (*captureExp, error) {
+	key := expKey{values: vals.key, text: text, kind: kind}
+
+	c.mu.Lock()
+	exp, ok := c.captures[key]
+	c.mu.Unlock()
+
+	if ok {
+		return exp, nil
+	}
+
+	exp, err := compile()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.captures == nil {
+		c.captures = make(map[expKey]*captureExp)
+	}
+	c.captures[key] = exp
+
+	return exp, nil
+}
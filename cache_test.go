@@ -0,0 +1,152 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const benchmarkTemplate = `Copyright (c) {{ .YEAR }} {{ .COMPANY }}
+
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.`
+
+func BenchmarkAnalyzer_Analyze(b *testing.B) {
+	for _, test := range []struct {
+		name, company string
+	}{
+		{name: "match", company: "Acme Inc."},
+		{name: "mismatch", company: "Other Inc."},
+	} {
+		for _, cached := range []bool{true, false} {
+			name := test.name + "/cached"
+			if !cached {
+				name = test.name + "/uncached"
+			}
+
+			b.Run(name, func(b *testing.B) {
+				benchmarkAnalyze(b, test.company, cached)
+			})
+		}
+	}
+}
+
+func benchmarkAnalyze(b *testing.B, company string, cached bool) {
+	settings := &Settings{
+		Template: benchmarkTemplate,
+		Now: func() time.Time {
+			return time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+		},
+	}
+	settings.SetDelimiters("", "")
+	settings.SetValues(map[string]string{"COMPANY": `Acme Inc\.`})
+
+	src := "/*\n" + benchmarkTemplate + "\n*/\n\npackage a\n"
+	src = strings.NewReplacer("{{ .YEAR }}", "2026", "{{ .COMPANY }}", company).Replace(src)
+
+	path := filepath.Join(b.TempDir(), "a.go")
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		b.Fatal(err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), path, src, parser.ParseComments)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	a := &Analyzer{Settings: settings}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if !cached {
+			// Mod times stay cached, so only matching is measured.
+			a.matchers = matcherCache{}
+		}
+
+		diag, err := a.Analyze(path, file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if (diag == nil) != (company == "Acme Inc.") {
+			b.Fatalf("unexpected diagnostic: %v", diag)
+		}
+	}
+}
+
+func TestAnalyzer_MismatchShouldUseCache(t *testing.T) {
+	settings := &Settings{
+		Template: benchmarkTemplate,
+		Now: func() time.Time {
+			return time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+		},
+	}
+	settings.SetDelimiters("", "")
+	settings.SetValues(map[string]string{"COMPANY": `Acme Inc\.`})
+
+	src := "/*\n" + strings.NewReplacer("{{ .YEAR }}", "2026", "{{ .COMPANY }}", "Other Inc.").Replace(benchmarkTemplate) + "\n*/\n\npackage a\n"
+
+	path := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), path, src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Analyzer{Settings: settings}
+
+	var exps, captures int
+
+	for i := 0; i < 2; i++ {
+		diag, err := a.Analyze(path, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diag == nil {
+			t.Fatal("expected a diagnostic")
+		}
+
+		if i == 0 {
+			exps, captures = len(a.matchers.exps), len(a.matchers.captures)
+			continue
+		}
+
+		if captures == 0 || len(a.matchers.captures) != captures || len(a.matchers.exps) != exps {
+			t.Fatalf("regexps are not reused: %v/%v regexps, %v/%v capturing regexps", exps, len(a.matchers.exps), captures, len(a.matchers.captures))
+		}
+	}
+}
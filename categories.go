@@ -0,0 +1,110 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"fmt"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Diagnostic categories. They are stable and can be used to filter diagnostics.
+const (
+	// CategoryMissingHeader is a file without a header.
+	CategoryMissingHeader = "missing-header"
+	// CategoryTemplateMismatch is a header that doesn't match the templates.
+	CategoryTemplateMismatch = "template-mismatch"
+	// CategoryOutdatedYear is a header that matches the templates except the year.
+	CategoryOutdatedYear = "outdated-year"
+	// CategoryWrongCommentStyle is a header written in another comment style than required.
+	CategoryWrongCommentStyle = "wrong-comment-style"
+	// CategoryHeaderPosition is a header placed in a wrong place of the file.
+	CategoryHeaderPosition = "header-position"
+	// CategoryConfigError is an error of templates or values found while checking a file.
+	CategoryConfigError = "config-error"
+)
+
+// Categories lists all diagnostic categories.
+var Categories = []string{
+	CategoryMissingHeader,
+	CategoryTemplateMismatch,
+	CategoryOutdatedYear,
+	CategoryWrongCommentStyle,
+	CategoryHeaderPosition,
+	CategoryConfigError,
+}
+
+// Severity of diagnostics of a category
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// ParseSeverity returns the severity by its name. Empty name means SeverityError.
+func ParseSeverity(s string) (Severity, error) {
+	switch v := Severity(s); v {
+	case "":
+		return SeverityError, nil
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unknown severity %q", s)
+	}
+}
+
+// CategorySettings configures diagnostics of a category.
+type CategorySettings struct {
+	Disabled bool
+	Severity Severity
+}
+
+// Category returns settings of the category. Categories are enabled with SeverityError by default.
+func (c *Settings) Category(category string) CategorySettings {
+	result := c.Categories[category]
+	if result.Severity == "" {
+		result.Severity = SeverityError
+	}
+	return result
+}
+
+// enabled returns the diagnostic if its category is enabled.
+func (a *Analyzer) enabled(diag *analysis.Diagnostic) *analysis.Diagnostic {
+	if diag == nil || a.Settings.Category(diag.Category).Disabled {
+		return nil
+	}
+	return diag
+}
+
+// withSeverity prefixes the message with the severity of its category unless it is an error.
+// Analysis drivers don't have severities, so it's the only way to tell warnings from errors.
+func (a *Analyzer) withSeverity(diag analysis.Diagnostic) analysis.Diagnostic {
+	if severity := a.Settings.Category(diag.Category).Severity; severity != SeverityError {
+		diag.Message = fmt.Sprintf("%v: %v", severity, diag.Message)
+	}
+	return diag
+}
+
+func validateCategory(category string) error {
+	if !slices.Contains(Categories, category) {
+		return fmt.Errorf("unknown diagnostic category %q", category)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	goheader "github.com/denis-tingaikin/go-header"
+	"golang.org/x/tools/go/analysis"
+)
+
+const filesUsage = `Usage: go-header files [flags] [paths]
+
+Checks headers of non-Go files like shell scripts, YAML, Dockerfiles or proto files.
+Directories are walked recursively, hidden files and directories are skipped. Go files are
+skipped as well, they are checked by go-header ./...
+
+Flags:
+`
+
+// runFiles runs the file walking mode and returns the exit code. Like analysis drivers
+// it returns 1 for errors and 3 if any issue is found.
+func runFiles(args []string) int {
+	flags := flag.NewFlagSet("files", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), filesUsage)
+		flags.PrintDefaults()
+	}
+
+	configPath := flags.String("config", defaultConfigPath, "path to the configuration file")
+	now := flags.String("now", "", "reference time for YEAR values like 2025-06-01, overrides the config and SOURCE_DATE_EPOCH")
+	fix := flags.Bool("fix", false, "apply all suggested fixes")
+
+	_ = flags.Parse(args)
+
+	cfg, err := goheader.Parse(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *now != "" {
+		cfg.Now = *now
+	}
+
+	settings := &goheader.Settings{}
+	if err := cfg.FillSettings(settings); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	analyzer := goheader.Analyzer{Settings: settings}
+	fset := token.NewFileSet()
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var exitCode int
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			if strings.HasSuffix(path, ".go") || !goheader.SupportsFile(path) {
+				return nil
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			diag, err := analyzer.AnalyzeFile(fset, path, content)
+			if err != nil {
+				switch category := settings.Category(goheader.CategoryConfigError); {
+				case category.Disabled:
+					return nil
+				case category.Severity != goheader.SeverityError:
+					fmt.Fprintf(os.Stderr, "%v: %v: %v\n", path, category.Severity, err)
+					return nil
+				}
+				return fmt.Errorf("%v: %w", path, err)
+			}
+
+			if diag == nil {
+				return nil
+			}
+
+			if *fix && len(diag.SuggestedFixes) > 0 {
+				return applyFix(fset, path, content, diag.SuggestedFixes[0])
+			}
+
+			severity := settings.Category(diag.Category).Severity
+			if severity == goheader.SeverityError {
+				fmt.Fprintf(os.Stderr, "%v: %v\n", fset.Position(diag.Pos), diag.Message)
+				exitCode = 3
+			} else {
+				fmt.Fprintf(os.Stderr, "%v: %v: %v\n", fset.Position(diag.Pos), severity, diag.Message)
+			}
+			for _, related := range diag.Related {
+				fmt.Fprintf(os.Stderr, "\t%v: %v\n", fset.Position(related.Pos), related.Message)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	return exitCode
+}
+
+func applyFix(fset *token.FileSet, path string, content []byte, fix analysis.SuggestedFix) error {
+	var result []byte
+	var last int
+
+	for _, edit := range fix.TextEdits {
+		start := fset.Position(edit.Pos).Offset
+		end := fset.Position(edit.End).Offset
+
+		result = append(result, content[last:start]...)
+		result = append(result, edit.NewText...)
+		last = end
+	}
+
+	result = append(result, content[last:]...)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, result, info.Mode())
+}
@@ -18,6 +18,9 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"os"
+	"time"
 
 	goheader "github.com/denis-tingaikin/go-header"
 	"golang.org/x/tools/go/analysis/singlechecker"
@@ -26,6 +29,10 @@ import (
 const defaultConfigPath = ".go-header.yml"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "files" {
+		os.Exit(runFiles(os.Args[2:]))
+	}
+
 	cfgFlags := &ConfigFlag{
 		configPath: defaultConfigPath,
 		settings:   &goheader.Settings{},
@@ -34,6 +41,16 @@ func main() {
 	var flagSet flag.FlagSet
 
 	flagSet.Var(cfgFlags, "config", "path to the configuration file")
+	flagSet.BoolFunc("print-mod-time", "print the modification year of each file and its source (git, filesystem or now)", func(string) error {
+		cfgFlags.settings.ModTimeReporter = func(path string, modTime time.Time, source goheader.ModTimeSource) {
+			fmt.Fprintf(os.Stderr, "%v: %v (%v)\n", path, modTime.Year(), source)
+		}
+		return nil
+	})
+	flagSet.Func("now", "reference time for YEAR values like 2025-06-01, overrides the config and SOURCE_DATE_EPOCH", func(now string) error {
+		cfgFlags.now = now
+		return cfgFlags.Set(cfgFlags.configPath)
+	})
 
 	analyser := goheader.New(cfgFlags.settings)
 
@@ -44,11 +61,12 @@ func main() {
 
 type ConfigFlag struct {
 	configPath string
+	now        string
 
 	settings *goheader.Settings
 }
 
-func (c ConfigFlag) String() string {
+func (c *ConfigFlag) String() string {
 	if len(c.configPath) != 0 {
 		// Ignore errors because `String` is called before `Set`.
 		cfg, _ := goheader.Parse(c.configPath)
@@ -60,7 +78,7 @@ func (c ConfigFlag) String() string {
 	return c.configPath
 }
 
-func (c ConfigFlag) Set(w string) error {
+func (c *ConfigFlag) Set(w string) error {
 	if w == "" {
 		w = defaultConfigPath
 	}
@@ -73,6 +91,10 @@ func (c ConfigFlag) Set(w string) error {
 			return err
 		}
 
+		if c.now != "" {
+			cfg.Now = c.now
+		}
+
 		err = cfg.FillSettings(c.settings)
 		if err != nil {
 			return err
@@ -17,10 +17,12 @@
 package goheader
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,10 +31,110 @@ import (
 
 // Experimental represents config params for enabling experimental / work in progress features
 type Experimental struct {
-	// CGO if true enables support for cgo files. Currently positioning of issues can be float.
+	// CGO if true enables support for cgo files.
 	CGO bool `yaml:"cgo"`
 }
 
+// TemplateConfig represents a named template in the config
+type TemplateConfig struct {
+	// Name is used for referring to the template in diagnostics.
+	Name string `yaml:"name"`
+	// Template is template for checking. Uses values.
+	Template string `yaml:"template"`
+	// TemplatePath path to the template file.
+	TemplatePath string `yaml:"template-path"`
+}
+
+// Var represents a value from the vars section. It can be set as a regexp string, as a list of literals
+// or as a mapping.
+type Var struct {
+	// Value is a regexp for checking.
+	Value string `yaml:"value"`
+	// Values is a list of literals. The var matches any of them, fixes use Fix or the first one.
+	Values []string `yaml:"values"`
+	// Fix is a literal used in suggested fixes instead of the regexp. Can refer to other values.
+	Fix string `yaml:"fix"`
+	// Keep means that suggested fixes reuse the text matched by the var in the existing header.
+	Keep bool `yaml:"keep"`
+}
+
+func (v *Var) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*v = Var{}
+		return node.Decode(&v.Value)
+	case yaml.SequenceNode:
+		*v = Var{}
+		if len(node.Content) == 0 {
+			return fmt.Errorf("line %v: list of values must not be empty", node.Line)
+		}
+		return node.Decode(&v.Values)
+	}
+
+	type plain Var
+
+	if err := node.Decode((*plain)(v)); err != nil {
+		return err
+	}
+
+	// An empty list would become an empty regexp.
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key, list := node.Content[i], node.Content[i+1]; key.Value == "values" && len(list.Content) == 0 {
+			return fmt.Errorf("line %v: list of values must not be empty", list.Line)
+		}
+	}
+
+	return nil
+}
+
+// CommentStyleConfig represents a custom comment style. Either Prefix or Start and End must be set.
+type CommentStyleConfig struct {
+	// Name is used for referring to the style.
+	Name string `yaml:"name"`
+	// Prefix is written before each line of line comments, e.g. "// " or "//".
+	Prefix string `yaml:"prefix"`
+	// Start is the opening marker of block comments, e.g. "/**".
+	Start string `yaml:"start"`
+	// End is the closing marker of block comments, e.g. "*/".
+	End string `yaml:"end"`
+	// LinePrefix is written before each line of block comments, e.g. " * ".
+	LinePrefix string `yaml:"line-prefix"`
+}
+
+// CategoryConfig configures diagnostics of a category
+type CategoryConfig struct {
+	// Enabled can disable the category. The default is true.
+	Enabled *bool `yaml:"enabled"`
+	// Severity is error, warning or info. The default is error.
+	Severity string `yaml:"severity"`
+}
+
+// MatcherConfig configures how headers are compared with templates
+type MatcherConfig struct {
+	// Type is regexp or lines. The default is regexp.
+	Type string `yaml:"type"`
+	// Whitespace is exact, trim or collapse. The default is trim.
+	Whitespace string `yaml:"whitespace"`
+	// OptionalLines are template lines that can be missing in headers.
+	OptionalLines []string `yaml:"optional-lines"`
+}
+
+// RuleConfig represents settings for files matching the paths
+type RuleConfig struct {
+	// Paths are glob patterns like `third_party/**` or `cmd/**/*.go`. The most specific matched rule wins.
+	Paths []string `yaml:"paths"`
+	// Skip disables checking of matched files.
+	Skip bool `yaml:"skip"`
+	// Template is template for matched files.
+	Template string `yaml:"template"`
+	// TemplatePath path to the template file for matched files.
+	TemplatePath string `yaml:"template-path"`
+	// Templates is a list of named templates for matched files.
+	Templates []TemplateConfig `yaml:"templates"`
+	// Vars is map of values for matched files. Overrides the top level vars.
+	Vars map[string]Var `yaml:"vars"`
+}
+
 // Config represents go-header linter setup parameters
 type Config struct {
 	// Values is map of values. Supports two types 'const` and `regexp`. Values can be used recursively.
@@ -42,14 +144,51 @@ type Config struct {
 	Template string `yaml:"template"`
 	// TemplatePath path to the template file. Useful if need to load the template from a specific file.
 	TemplatePath string `yaml:"template-path"`
+	// Templates is a list of named templates. A file passes if it matches any of them.
+	// If Template or TemplatePath is set too, it is used as the default template for fixes.
+	Templates []TemplateConfig `yaml:"templates"`
 	// Vars is map of values. Values can be used recursively.
-	Vars map[string]string `yaml:"vars"`
+	Vars map[string]Var `yaml:"vars"`
+	// Rules allow to use other templates or vars for specific paths.
+	Rules []RuleConfig `yaml:"rules"`
+	// CommentStyles are custom comment styles. They are detected before the built-in ones.
+	CommentStyles []CommentStyleConfig `yaml:"comment-styles"`
+	// Categories configure diagnostic categories like missing-header or template-mismatch.
+	Categories map[string]CategoryConfig `yaml:"categories"`
+	// Match defines how the template must cover the header: contains, prefix or exact. The default is contains.
+	Match string `yaml:"match"`
+	// Matcher configures how headers are compared with templates. Whitespace and optional lines
+	// are supported by the lines matcher only.
+	Matcher MatcherConfig `yaml:"matcher"`
+	// Generated defines how generated files are checked: check, skip or check-after-marker. The default is check.
+	Generated string `yaml:"generated"`
+	// HeaderPlacement defines where the header must be placed relative to build constraints:
+	// any, before-build-constraints or after-build-constraints. The default is any.
+	HeaderPlacement string `yaml:"header-placement"`
+	// CheckPosition enables checking that the header is separated from the package doc comment
+	// and the package clause by a blank line.
+	CheckPosition bool `yaml:"check-position"`
+	// CommentStyle is the required style of headers: line, block, block-star, a custom style or any.
+	// The default is any, fixes keep the style of the existing header.
+	CommentStyle string `yaml:"comment-style"`
+	// YearRangePolicy defines how fixes update year ranges: extend, replace, first-year or list. The default is extend.
+	YearRangePolicy string `yaml:"year-range-policy"`
+	// DateSource defines which date of git commits is used for MOD_YEAR and CREATED_YEAR: committer or author.
+	// The default is committer.
+	DateSource string `yaml:"date-source"`
+	// Now is the reference time for YEAR values like 2025-06-01. Overrides SOURCE_DATE_EPOCH.
+	// The default is the current time.
+	Now string `yaml:"now"`
 	// Delims represents a string marker for values. The default is "{{}}".
 	Delims string `yaml:"delims"`
 	// Parallel means a number of goroutines to proccess files. Default runtime.NumCPU()
 	Parallel int `yaml:"parallel"`
 	// Experimental is config for enabling experimental / work in progress features.
 	Experimental Experimental `yaml:"experimental"`
+
+	// path and lines locate templates in the config file, see Parse.
+	path  string
+	lines map[string]int
 }
 
 func (c *Config) GetDelims() string {
@@ -66,7 +205,45 @@ func (c *Config) GetParallel() int {
 	return c.Parallel
 }
 
+// GetNow returns the reference time for year values. It is taken from the now field or from
+// the SOURCE_DATE_EPOCH environment variable. The zero time is returned if neither is set.
+func (c *Config) GetNow() (time.Time, error) {
+	if c.Now != "" {
+		for _, layout := range []string{time.DateOnly, time.RFC3339} {
+			if t, err := time.Parse(layout, c.Now); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("now should be a date like 2025-06-01 or a RFC 3339 time: %q", c.Now)
+	}
+
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("SOURCE_DATE_EPOCH should be a number of seconds: %q", epoch)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	return time.Time{}, nil
+}
+
+func (c *Config) now() (time.Time, error) {
+	now, err := c.GetNow()
+	if err != nil || !now.IsZero() {
+		return now, err
+	}
+	return time.Now(), nil
+}
+
 func (c *Config) GetValues() (map[string]Value, error) {
+	if _, err := c.now(); err != nil {
+		return nil, err
+	}
+	return c.getValues(), nil
+}
+
+func (c *Config) getValues() map[string]Value {
 	result := builtInValues()
 
 	createConst := func(raw string) Value {
@@ -86,19 +263,29 @@ func (c *Config) GetValues() (map[string]Value, error) {
 
 	appendValues(c.Values["const"], createConst)
 	appendValues(c.Values["regexp"], createRegexp)
-	appendValues(c.Vars, createRegexp)
+	appendVars(result, c.Vars)
 
-	return result, nil
+	return result
 }
 
+func appendVars(values map[string]Value, vars map[string]Var) {
+	for k, v := range vars {
+		if len(v.Values) > 0 {
+			values[strings.ToLower(k)] = &ListValue{Values: v.Values, Fix: v.Fix, Keep: v.Keep}
+			values[strings.ToUpper(k)] = &ListValue{Values: v.Values, Fix: v.Fix, Keep: v.Keep}
+			continue
+		}
+		values[strings.ToLower(k)] = &RegexpValue{RawValue: v.Value, Fix: v.Fix, Keep: v.Keep}
+		values[strings.ToUpper(k)] = &RegexpValue{RawValue: v.Value, Fix: v.Fix, Keep: v.Keep}
+	}
+}
+
+// builtInValues returns values available in all templates. YEAR is added by the analyzer
+// from Settings.Now unless it is overridden.
 func builtInValues() map[string]Value {
 	var result = make(map[string]Value)
-	year := fmt.Sprint(time.Now().Year())
-	result["YEAR_RANGE"] = &RegexpValue{
-		RawValue: `((20\d\d\-{{.YEAR}})|({{.YEAR}}))`,
-	}
-	result["YEAR"] = &ConstValue{
-		RawValue: year,
+	result["YEAR_RANGE"] = &YearRangeValue{
+		RawValue: "{{.YEAR}}",
 	}
 	return result
 }
@@ -131,6 +318,191 @@ func (c *Config) getTemplate() (string, error) {
 	return c.Template, nil
 }
 
+// templateSource returns the location of the template set by the key or read from tmplPath.
+func (c *Config) templateSource(key, tmplPath string) TemplateSource {
+	if line, ok := c.lines[key]; ok && c.path != "" {
+		return TemplateSource{Path: c.path, Line: line}
+	}
+	if tmplPath != "" {
+		return templateFileSource(tmplPath)
+	}
+	return TemplateSource{}
+}
+
+// GetTemplates returns named templates from the templates section.
+func (c *Config) GetTemplates() ([]Template, error) {
+	return c.readTemplates("templates", c.Templates)
+}
+
+// GetRules returns rules with resolved templates and values.
+func (c *Config) GetRules() ([]Rule, error) {
+	if _, err := c.now(); err != nil {
+		return nil, err
+	}
+	return c.getRules()
+}
+
+func (c *Config) getRules() ([]Rule, error) {
+	var result []Rule
+
+	for i, r := range c.Rules {
+		if len(r.Paths) == 0 {
+			return nil, errors.New("rule must have at least one path")
+		}
+
+		for _, pattern := range r.Paths {
+			if err := validateGlob(pattern); err != nil {
+				return nil, fmt.Errorf("rule %v: path %q: %w", i, pattern, err)
+			}
+		}
+
+		rule := Rule{Paths: r.Paths, Skip: r.Skip}
+
+		tmpl, err := readTemplate(r.Template, r.TemplatePath)
+		if err != nil {
+			return nil, err
+		}
+		if tmpl != "" {
+			rule.Templates = append(rule.Templates, Template{
+				Name:   defaultTemplateName,
+				Text:   migrateOldConfig(tmpl, c.GetDelims()),
+				Source: c.templateSource(fmt.Sprintf("rules.%v.template", i), r.TemplatePath),
+			})
+		}
+
+		templates, err := c.readTemplates(fmt.Sprintf("rules.%v.templates", i), r.Templates)
+		if err != nil {
+			return nil, err
+		}
+		rule.Templates = append(rule.Templates, templates...)
+
+		if len(r.Vars) > 0 {
+			vals := c.getValues()
+			appendVars(vals, r.Vars)
+			rule.Values = vals
+		}
+
+		result = append(result, rule)
+	}
+
+	return result, nil
+}
+
+// GetSettings returns settings of the matcher. Optional lines are migrated like templates
+// with the delimiters, so they are compared with template lines written in the same way.
+func (c *MatcherConfig) GetSettings(delims string) (MatcherSettings, error) {
+	var err error
+	var result MatcherSettings
+
+	for _, line := range c.OptionalLines {
+		result.OptionalLines = append(result.OptionalLines, migrateOldConfig(line, delims))
+	}
+
+	result.Type, err = ParseMatcherType(c.Type)
+	if err != nil {
+		return result, err
+	}
+
+	result.Whitespace, err = ParseWhitespace(c.Whitespace)
+
+	return result, err
+}
+
+// GetCategories returns settings of diagnostic categories.
+func (c *Config) GetCategories() (map[string]CategorySettings, error) {
+	result := make(map[string]CategorySettings)
+
+	for name, category := range c.Categories {
+		if err := validateCategory(name); err != nil {
+			return nil, err
+		}
+
+		severity, err := ParseSeverity(category.Severity)
+		if err != nil {
+			return nil, err
+		}
+
+		result[name] = CategorySettings{
+			Disabled: category.Enabled != nil && !*category.Enabled,
+			Severity: severity,
+		}
+	}
+
+	return result, nil
+}
+
+// GetCommentStyles returns custom comment styles.
+func (c *Config) GetCommentStyles() ([]CommentStyle, error) {
+	var result []CommentStyle
+
+	for i, style := range c.CommentStyles {
+		name := style.Name
+		if name == "" {
+			name = fmt.Sprintf("comment-style-%v", i+1)
+		}
+
+		switch {
+		case style.Prefix != "" && style.Start == "" && style.End == "":
+			result = append(result, NewLineCommentStyle(name, style.Prefix))
+		case style.Prefix == "" && style.Start != "" && style.End != "":
+			result = append(result, NewBlockCommentStyle(name, style.Start, style.End, style.LinePrefix))
+		default:
+			return nil, fmt.Errorf("comment style %q must have either prefix or start and end", name)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *Config) readTemplates(key string, templates []TemplateConfig) ([]Template, error) {
+	var result []Template
+
+	for i, t := range templates {
+		text, err := readTemplate(t.Template, t.TemplatePath)
+		if err != nil {
+			return nil, err
+		}
+		if text == "" {
+			return nil, fmt.Errorf("template %q is empty", t.Name)
+		}
+		name := t.Name
+		if name == "" {
+			name = fmt.Sprintf("template-%v", i+1)
+		}
+		result = append(result, Template{
+			Name:   name,
+			Text:   migrateOldConfig(text, c.GetDelims()),
+			Source: c.templateSource(fmt.Sprintf("%v.%v.template", key, i), t.TemplatePath),
+		})
+	}
+
+	return result, nil
+}
+
+func readTemplate(tmpl, tmplPath string) (string, error) {
+	if tmpl != "" || tmplPath == "" {
+		return tmpl, nil
+	}
+
+	b, err := os.ReadFile(tmplPath)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// templateKeywords are actions kept as is by migrateOldConfig.
+var templateKeywords = map[string]bool{
+	optionalBlock: true,
+	repeatBlock:   true,
+	"end":         true,
+	"else":        true,
+	"if":          true,
+	"range":       true,
+	"with":        true,
+}
+
 func migrateOldConfig(input string, delims string) string {
 	left := delims[:len(delims)/2]
 	right := delims[len(delims)/2:]
@@ -144,10 +516,24 @@ func migrateOldConfig(input string, delims string) string {
 		inner := match[2 : len(match)-2]
 		inner = strings.TrimSpace(inner)
 
+		// Trim markers are kept as is.
+		left, right := left, right
+		if rest, ok := strings.CutPrefix(inner, "- "); ok {
+			inner, left = strings.TrimSpace(rest), left+"-"
+		}
+		if rest, ok := strings.CutSuffix(inner, " -"); ok {
+			inner, right = strings.TrimSpace(rest), "-"+right
+		}
+
 		if strings.HasPrefix(inner, ".") {
 			return fmt.Sprintf("%v %v %v", left, inner, right)
 		}
 
+		// Template blocks and actions are not old style values.
+		if keyword, _, _ := strings.Cut(inner, " "); templateKeywords[keyword] {
+			return fmt.Sprintf("%v %v %v", left, inner, right)
+		}
+
 		// Replace spaces with underscores
 		convertedInner := strings.ReplaceAll(inner, " ", "_")
 
@@ -178,17 +564,96 @@ func (c *Config) FillSettings(settings *Settings) error {
 	}
 	if tmpl != "" {
 		settings.Template = tmpl
+		settings.TemplateSource = c.templateSource("template", c.TemplatePath)
 	}
 
-	vals, err := c.GetValues()
+	templates, err := c.GetTemplates()
+	if err != nil {
+		return err
+	}
+	if len(templates) > 0 {
+		settings.Templates = templates
+	}
+
+	styles, err := c.GetCommentStyles()
+	if err != nil {
+		return err
+	}
+	if len(styles) > 0 {
+		settings.CommentStyles = styles
+	}
+
+	settings.CommentStyle, err = commentStyleByName(c.CommentStyle, settings.CommentStyles)
 	if err != nil {
 		return err
 	}
 
+	now, err := c.GetNow()
+	if err != nil {
+		return err
+	}
+	if !now.IsZero() {
+		settings.Now = func() time.Time { return now }
+	}
+
+	rules, err := c.getRules()
+	if err != nil {
+		return err
+	}
+	settings.Rules = rules
+
+	vals := c.getValues()
+
 	if len(vals) > 0 {
 		settings.Values = vals
 	}
 
+	year := fmt.Sprint(settings.now().Year())
+	if err := validateFixes(settings.Values, year); err != nil {
+		return err
+	}
+	for i, rule := range settings.Rules {
+		if err := validateFixes(rule.Values, year); err != nil {
+			return fmt.Errorf("rule %v: %w", i, err)
+		}
+	}
+
+	settings.YearRangePolicy, err = ParseYearRangePolicy(c.YearRangePolicy)
+	if err != nil {
+		return err
+	}
+
+	settings.DateSource, err = ParseDateSource(c.DateSource)
+	if err != nil {
+		return err
+	}
+
+	settings.Categories, err = c.GetCategories()
+	if err != nil {
+		return err
+	}
+
+	settings.Match, err = ParseMatchMode(c.Match)
+	if err != nil {
+		return err
+	}
+
+	settings.Matcher, err = c.Matcher.GetSettings(c.GetDelims())
+	if err != nil {
+		return err
+	}
+
+	settings.Generated, err = ParseGeneratedPolicy(c.Generated)
+	if err != nil {
+		return err
+	}
+
+	settings.HeaderPlacement, err = ParseHeaderPlacement(c.HeaderPlacement)
+	if err != nil {
+		return err
+	}
+
+	settings.CheckPosition = c.CheckPosition
 	settings.Parallel = c.GetParallel()
 	settings.CGO = c.Experimental.CGO
 
@@ -208,18 +673,78 @@ func Parse(data string) (*Config, error) {
 		return nil, err
 	}
 
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err == nil {
+		cfg.path = data
+		cfg.lines = make(map[string]int)
+		yamlLines(&node, "", cfg.lines)
+	}
+
 	return cfg, nil
 }
 
+const defaultTemplateName = "default"
+
+// Template is a named license header template
+type Template struct {
+	Name string
+	Text string
+	// Source is the location of the template, it is empty if unknown.
+	Source TemplateSource
+}
+
 type Settings struct {
-	Values                map[string]Value
-	Template              string
+	Values   map[string]Value
+	Template string
+	// TemplateSource is the location of Template, diagnostics refer to it.
+	TemplateSource TemplateSource
+	// Templates are alternative templates. A file passes if it matches Template or any of Templates.
+	Templates []Template
+	// Rules override templates and values for specific paths.
+	Rules []Rule
+	// CommentStyles are custom comment styles. They are detected before the built-in ones.
+	CommentStyles []CommentStyle
+	// Categories configure diagnostic categories, see Settings.Category.
+	Categories map[string]CategorySettings
+	// Match defines how the template must cover the header. The default is MatchContains.
+	Match MatchMode
+	// Matcher configures how headers are compared with templates. The regexp matcher is used by default.
+	Matcher MatcherSettings
+	// Generated defines how generated files are checked. The default is CheckGenerated.
+	Generated GeneratedPolicy
+	// HeaderPlacement defines where the header must be placed relative to build constraints.
+	// The default is AnyPlacement.
+	HeaderPlacement HeaderPlacement
+	// CheckPosition enables checking that the header is not a part of the package doc comment.
+	CheckPosition bool
+	// CommentStyle is the required style of headers. Headers in other styles are reported.
+	// Any style is accepted if it is nil.
+	CommentStyle          CommentStyle
 	LeftDelim, RightDelim string
-	Parallel              int
-	CGO                   bool
+	// YearRangePolicy defines how fixes update year ranges. The default is ExtendYearRange.
+	YearRangePolicy YearRangePolicy
+	// DateSource defines which date of git commits is used for file years. The default is CommitterDate.
+	DateSource DateSource
+	Parallel   int
+	CGO        bool
+	// Now returns the reference time for YEAR values and for files without modification times.
+	// The current time is used if it is nil.
+	Now func() time.Time
+	// ModTimeReporter if set is called with the modification time of each checked file and its source.
+	// It must be safe for concurrent use.
+	ModTimeReporter func(path string, modTime time.Time, source ModTimeSource)
+}
+
+func (c *Settings) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
 }
 
 func (c *Settings) SetTemplate(tmplStr, tmplPath string) error {
+	c.TemplateSource = TemplateSource{}
+
 	if tmplStr != "" {
 		c.Template = tmplStr
 		return nil
@@ -230,6 +755,8 @@ func (c *Settings) SetTemplate(tmplStr, tmplPath string) error {
 		return nil
 	}
 
+	c.TemplateSource = templateFileSource(tmplPath)
+
 	b, err := os.ReadFile(tmplPath)
 	if err != nil {
 		return err
@@ -240,6 +767,23 @@ func (c *Settings) SetTemplate(tmplStr, tmplPath string) error {
 	return nil
 }
 
+// GetTemplates returns the templates to check against. The first one is used for fixes.
+func (c *Settings) GetTemplates() []Template {
+	var result []Template
+
+	if c.Template != "" {
+		result = append(result, Template{Name: defaultTemplateName, Text: c.Template, Source: c.TemplateSource})
+	}
+
+	for _, t := range c.Templates {
+		if t.Text != "" {
+			result = append(result, t)
+		}
+	}
+
+	return result
+}
+
 func (c *Settings) SetDelimiters(left, right string) {
 	c.LeftDelim = left
 	if left == "" {
@@ -255,16 +799,10 @@ func (c *Settings) SetDelimiters(left, right string) {
 func (c *Settings) SetValues(values map[string]string) {
 	result := builtInValues()
 
-	appendValues := func(m map[string]string, create func(string) Value) {
-		for k, v := range m {
-			result[strings.ToLower(k)] = create(v)
-			result[strings.ToUpper(k)] = create(v)
-		}
+	for k, v := range values {
+		result[strings.ToLower(k)] = &RegexpValue{RawValue: v}
+		result[strings.ToUpper(k)] = &RegexpValue{RawValue: v}
 	}
 
-	appendValues(values, func(raw string) Value {
-		return &RegexpValue{RawValue: raw}
-	})
-
 	c.Values = result
 }
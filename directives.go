@@ -0,0 +1,247 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// HeaderPlacement defines where the header must be placed relative to build constraints
+type HeaderPlacement string
+
+const (
+	// AnyPlacement accepts the header before and after build constraints. New headers are added on top of the file.
+	AnyPlacement HeaderPlacement = "any"
+	// BeforeBuildConstraints requires the header on top of the file. Only line comments can precede build constraints.
+	BeforeBuildConstraints HeaderPlacement = "before-build-constraints"
+	// AfterBuildConstraints requires the header after build constraints.
+	AfterBuildConstraints HeaderPlacement = "after-build-constraints"
+)
+
+// ParseHeaderPlacement returns the placement by its name. Empty name means AnyPlacement.
+func ParseHeaderPlacement(s string) (HeaderPlacement, error) {
+	switch p := HeaderPlacement(s); p {
+	case "":
+		return AnyPlacement, nil
+	case AnyPlacement, BeforeBuildConstraints, AfterBuildConstraints:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown header placement %q", s)
+	}
+}
+
+// isDirective reports whether the line comment is a directive which is not a part of the header:
+// Go directives like //go:build, //go:generate or //lint:file-ignore, //nolint and // +build.
+func isDirective(comment string) bool {
+	c, ok := strings.CutPrefix(comment, "//")
+	if !ok {
+		return false
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(c), "+build") {
+		return true
+	}
+
+	if c == "nolint" || strings.HasPrefix(c, "nolint:") || strings.HasPrefix(c, "nolint ") {
+		return true
+	}
+
+	if strings.HasPrefix(c, "line ") || strings.HasPrefix(c, "extern ") || strings.HasPrefix(c, "export ") {
+		return true
+	}
+
+	// The same rule as ast.CommentGroup.Text uses: //[a-z0-9]+:[a-z0-9]
+	colon := strings.Index(c, ":")
+	if colon <= 0 || colon+1 >= len(c) {
+		return false
+	}
+
+	for i := 0; i <= colon+1; i++ {
+		if i == colon {
+			continue
+		}
+		b := c[i]
+		if !('a' <= b && b <= 'z' || '0' <= b && b <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isBuildConstraint(comment string) bool {
+	c, ok := strings.CutPrefix(comment, "//")
+	return ok && (strings.HasPrefix(c, "go:build") || strings.HasPrefix(strings.TrimSpace(c), "+build"))
+}
+
+// lineComments returns the comments of the group without directives.
+func lineComments(comment *ast.CommentGroup) []*ast.Comment {
+	var result []*ast.Comment
+
+	for _, c := range comment.List {
+		if !isDirective(c.Text) {
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+// keptDirectives returns directives between the first and the last line of the header. Fixes write them
+// after the new header. Directives before and after the header are out of the replaced range.
+func keptDirectives(comment *ast.CommentGroup, lines []*ast.Comment) []string {
+	var result []string
+
+	for _, c := range comment.List {
+		if c.Pos() > lines[0].Pos() && c.End() < lines[len(lines)-1].End() && isDirective(c.Text) {
+			result = append(result, c.Text)
+		}
+	}
+
+	return result
+}
+
+// buildConstraints returns comment groups with build constraints before the package clause.
+func buildConstraints(file *ast.File) []*ast.CommentGroup {
+	var result []*ast.CommentGroup
+
+	for _, comment := range file.Comments {
+		if comment.Pos() > file.Package {
+			break
+		}
+		for _, c := range comment.List {
+			if isBuildConstraint(c.Text) {
+				result = append(result, comment)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// newHeaderPos returns the position for a new header. It is after build constraints or after the generated
+// code marker if required, or the start of the file.
+func (a *Analyzer) newHeaderPos(file *ast.File, marker *ast.CommentGroup, afterMarker bool) token.Pos {
+	pos := file.FileStart
+
+	if constraints := buildConstraints(file); a.Settings.HeaderPlacement == AfterBuildConstraints && len(constraints) > 0 {
+		pos = constraints[len(constraints)-1].End()
+	}
+
+	if marker != nil && afterMarker && marker.End() > pos {
+		pos = marker.End()
+	}
+
+	return pos
+}
+
+// newHeaderText separates the rendered header inserted at pos from the content around it.
+func newHeaderText(file *ast.File, pos token.Pos, header string) string {
+	if pos != file.FileStart {
+		return "\n\n" + header
+	}
+
+	next := file.Package
+	if len(file.Comments) > 0 && file.Comments[0].Pos() < next {
+		next = file.Comments[0].Pos()
+	}
+
+	if next == pos {
+		return header + "\n\n"
+	}
+
+	return header + "\n"
+}
+
+// checkPlacement reports a header on the wrong side of build constraints. The fix moves the header
+// comment group.
+func (a *Analyzer) checkPlacement(file *ast.File, comment *ast.CommentGroup) *analysis.Diagnostic {
+	constraints := buildConstraints(file)
+	if len(constraints) == 0 || slices.Contains(constraints, comment) {
+		return nil
+	}
+
+	first, last := constraints[0], constraints[len(constraints)-1]
+
+	switch {
+	case a.Settings.HeaderPlacement == AfterBuildConstraints && comment.Pos() < last.Pos():
+		return moveHeader(file, comment, "header must be placed after build constraints", last.End(), true)
+	case a.Settings.HeaderPlacement == BeforeBuildConstraints && comment.Pos() > first.Pos():
+		return moveHeader(file, comment, "header must be placed before build constraints", first.Pos(), false)
+	default:
+		return nil
+	}
+}
+
+// moveHeader returns a diagnostic with a fix that moves the header comment group to pos. If after is true
+// the header is written after the comment group ending at pos, otherwise before the group starting at pos.
+func moveHeader(file *ast.File, comment *ast.CommentGroup, message string, pos token.Pos, after bool) *analysis.Diagnostic {
+	var texts []string
+	for _, c := range comment.List {
+		texts = append(texts, c.Text)
+	}
+	text := strings.Join(texts, "\n")
+
+	// The header is removed with blank lines after it.
+	next := file.Package
+	for _, c := range file.Comments {
+		if c.Pos() > comment.Pos() && c.Pos() < next {
+			next = c.Pos()
+			break
+		}
+	}
+	remove := analysis.TextEdit{Pos: comment.Pos(), End: next}
+
+	var edits []analysis.TextEdit
+	if after {
+		edits = []analysis.TextEdit{remove, {Pos: pos, End: pos, NewText: []byte("\n\n" + text)}}
+	} else {
+		edits = []analysis.TextEdit{{Pos: pos, End: pos, NewText: []byte(text + "\n\n")}, remove}
+	}
+
+	return &analysis.Diagnostic{
+		Pos:            comment.Pos(),
+		End:            comment.End(),
+		Message:        message,
+		Category:       CategoryHeaderPosition,
+		SuggestedFixes: []analysis.SuggestedFix{{TextEdits: edits}},
+	}
+}
+
+// startsToken reports whether a comment or the package clause starts at the position.
+func startsToken(file *ast.File, pos token.Pos) bool {
+	if file.Package == pos {
+		return true
+	}
+
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if c.Pos() == pos {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// commentSyntax describes comments of a file type. The first of styles is used for new headers.
+// Lines matching prologue must stay first in a file, headers are looked for and inserted after them.
+type commentSyntax struct {
+	line       string
+	blockStart string
+	blockEnd   string
+	styles     []CommentStyle
+	prologue   *regexp.Regexp
+}
+
+var (
+	hashSyntax   = commentSyntax{line: "#", styles: []CommentStyle{HashComment}}
+	cSyntax      = commentSyntax{line: "//", blockStart: "/*", blockEnd: "*/", styles: goCommentStyles}
+	cssSyntax    = commentSyntax{blockStart: "/*", blockEnd: "*/", styles: []CommentStyle{BlockComment, StarBlockComment}}
+	xmlSyntax    = commentSyntax{blockStart: "<!--", blockEnd: "-->", styles: []CommentStyle{XMLComment}}
+	dashesSyntax = commentSyntax{line: "--", styles: []CommentStyle{DashComment}}
+	// Parser directives like `# syntax=docker/dockerfile:1`.
+	dockerSyntax = commentSyntax{line: "#", styles: []CommentStyle{HashComment}, prologue: regexp.MustCompile(`(?i)^#\s*(syntax|escape|check)\s*=`)}
+	// Source encoding declarations like `# -*- coding: utf-8 -*-`, see PEP 263.
+	pythonSyntax = commentSyntax{line: "#", styles: []CommentStyle{HashComment}, prologue: regexp.MustCompile(`^[ \t\f]*#.*?coding[:=][ \t]*[-_.a-zA-Z0-9]+`)}
+	// XML declarations like `<?xml version="1.0"?>`.
+	xmlDeclSyntax = commentSyntax{blockStart: "<!--", blockEnd: "-->", styles: []CommentStyle{XMLComment}, prologue: regexp.MustCompile(`^\s*<\?xml\s.*\?>\s*$`)}
+)
+
+var extensionSyntaxes = map[string]commentSyntax{
+	".sh":         hashSyntax,
+	".bash":       hashSyntax,
+	".zsh":        hashSyntax,
+	".py":         pythonSyntax,
+	".rb":         hashSyntax,
+	".pl":         hashSyntax,
+	".yaml":       hashSyntax,
+	".yml":        hashSyntax,
+	".toml":       hashSyntax,
+	".tf":         hashSyntax,
+	".mk":         hashSyntax,
+	".dockerfile": dockerSyntax,
+	".proto":      cSyntax,
+	".c":          cSyntax,
+	".h":          cSyntax,
+	".cc":         cSyntax,
+	".cpp":        cSyntax,
+	".hpp":        cSyntax,
+	".java":       cSyntax,
+	".js":         cSyntax,
+	".ts":         cSyntax,
+	".rs":         cSyntax,
+	".swift":      cSyntax,
+	".kt":         cSyntax,
+	".css":        cssSyntax,
+	".html":       xmlSyntax,
+	".xml":        xmlDeclSyntax,
+	".md":         xmlSyntax,
+	".svg":        xmlDeclSyntax,
+	".sql":        dashesSyntax,
+	".lua":        dashesSyntax,
+}
+
+var nameSyntaxes = map[string]commentSyntax{
+	"Dockerfile":    dockerSyntax,
+	"Containerfile": dockerSyntax,
+	"Makefile":      hashSyntax,
+	"GNUmakefile":   hashSyntax,
+}
+
+func commentSyntaxFor(path string) (commentSyntax, bool) {
+	name := filepath.Base(path)
+
+	if s, ok := nameSyntaxes[name]; ok {
+		return s, true
+	}
+
+	// Dockerfile.dev, Makefile.common, etc.
+	if prefix, _, ok := strings.Cut(name, "."); ok {
+		if s, ok := nameSyntaxes[prefix]; ok {
+			return s, true
+		}
+	}
+
+	s, ok := extensionSyntaxes[strings.ToLower(filepath.Ext(name))]
+
+	return s, ok
+}
+
+// SupportsFile reports whether AnalyzeFile knows the comment syntax of the file.
+func SupportsFile(path string) bool {
+	_, ok := commentSyntaxFor(path)
+	return ok
+}
+
+// textHeader is a header comment found in a file. Offsets include the trailing line break.
+// If the header is not found start and end point to the place for a new header.
+type textHeader struct {
+	start, end int
+	comment    string
+}
+
+// findHeader returns the first comment of the content. A shebang line, the prologue and blank lines before it are skipped.
+func (s commentSyntax) findHeader(content string) (textHeader, bool) {
+	var h textHeader
+
+	if strings.HasPrefix(content, "#!") {
+		h.start = lineEnd(content, 0)
+	}
+
+	for s.prologue != nil && h.start < len(content) {
+		end := lineEnd(content, h.start)
+		if !s.prologue.MatchString(strings.TrimRight(content[h.start:end], "\r\n")) {
+			break
+		}
+		h.start = end
+	}
+
+	for h.start < len(content) {
+		end := lineEnd(content, h.start)
+		if strings.TrimSpace(content[h.start:end]) != "" {
+			break
+		}
+		h.start = end
+	}
+
+	h.end = h.start
+
+	trimmed := strings.TrimLeft(content[h.start:], " \t")
+
+	if s.line != "" && strings.HasPrefix(trimmed, s.line) {
+		for h.end < len(content) {
+			end := lineEnd(content, h.end)
+			if !strings.HasPrefix(strings.TrimSpace(content[h.end:end]), s.line) {
+				break
+			}
+			h.end = end
+		}
+
+		h.comment = strings.TrimRight(content[h.start:h.end], "\n")
+
+		return h, true
+	}
+
+	if s.blockStart != "" && strings.HasPrefix(trimmed, s.blockStart) {
+		commentStart := len(content) - len(trimmed)
+
+		bodyEnd := strings.Index(content[commentStart+len(s.blockStart):], s.blockEnd)
+		if bodyEnd < 0 {
+			return h, false
+		}
+		bodyEnd += commentStart + len(s.blockStart)
+
+		h.end = bodyEnd + len(s.blockEnd)
+		h.comment = content[commentStart:h.end]
+
+		if strings.TrimSpace(content[h.end:lineEnd(content, h.end)]) == "" {
+			h.end = lineEnd(content, h.end)
+		}
+
+		return h, true
+	}
+
+	return h, false
+}
+
+// lineEnd returns the offset after the line break of the line containing the offset.
+func lineEnd(content string, offset int) int {
+	if i := strings.IndexByte(content[offset:], '\n'); i >= 0 {
+		return offset + i + 1
+	}
+	return len(content)
+}
+
+// AnalyzeFile checks the header of a non-Go source file like a shell script, a YAML or a proto file.
+// The comment syntax is chosen by the file name, see SupportsFile. The file is added to fset,
+// positions of the returned diagnostic and its fix refer to it.
+func (a *Analyzer) AnalyzeFile(fset *token.FileSet, path string, content []byte) (*analysis.Diagnostic, error) {
+	syntax, ok := commentSyntaxFor(path)
+	if !ok {
+		return nil, nil
+	}
+
+	text := string(content)
+	header, found := syntax.findHeader(text)
+	style := a.detectStyle(syntax.styles, header.comment)
+	preferred := a.preferredStyle(syntax.styles, style)
+	headerText := style.Strip(header.comment)
+
+	checked, err := a.check(path, headerText, func(fix string) string {
+		fix = preferred.Render(fix) + "\n"
+		if !found && header.start < len(text) && text[header.start] != '\n' {
+			// Separate a new header from the content.
+			fix += "\n"
+		}
+		return fix
+	})
+	result := a.enabled(checked.diag)
+	if result == nil && err == nil && found && preferred != style {
+		result = a.enabled(wrongStyle(style, preferred, preferred.Render(strings.TrimSpace(headerText))+"\n"))
+	}
+	if result == nil {
+		return nil, err
+	}
+
+	file := fset.AddFile(path, -1, len(content))
+	file.SetLinesForContent(content)
+
+	result.Pos = file.Pos(header.start)
+	result.End = file.Pos(header.start + len(strings.TrimRight(text[header.start:header.end], "\n")))
+
+	if checked.line >= 0 {
+		commentStart := header.start + strings.Index(text[header.start:], header.comment)
+		lineStart := commentStart + lineOffset(header.comment, checked.line)
+		line, _, _ := strings.Cut(text[lineStart:], "\n")
+
+		result.Pos = file.Pos(lineStart)
+		result.End = file.Pos(lineStart + len(line))
+		result.Related = templateRelated(newSourceFiles(fset), checked.template, checked.lineIndex)
+	}
+
+	for i := range result.SuggestedFixes {
+		for j := range result.SuggestedFixes[i].TextEdits {
+			result.SuggestedFixes[i].TextEdits[j].Pos = file.Pos(header.start)
+			result.SuggestedFixes[i].TextEdits[j].End = file.Pos(header.end)
+		}
+	}
+
+	if len(checked.years) > 0 && result.Category == CategoryOutdatedYear {
+		commentStart := header.start + strings.Index(text[header.start:], header.comment)
+		setYearEdits(result, checked.years, func(i int) (token.Pos, string) {
+			line, _, _ := strings.Cut(header.comment[lineOffset(header.comment, i):], "\n")
+			return file.Pos(commentStart + lineOffset(header.comment, i)), line
+		})
+	}
+
+	return result, err
+}
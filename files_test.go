@@ -0,0 +1,133 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader_test
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	goheader "github.com/denis-tingaikin/go-header"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer_AnalyzeFile(t *testing.T) {
+	dir := filepath.Join(analysistest.TestData(), "files")
+
+	cfg, err := goheader.Parse(filepath.Join(dir, "files.yml"))
+	require.NoError(t, err)
+
+	settings := &goheader.Settings{}
+	require.NoError(t, cfg.FillSettings(settings))
+
+	list, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	for _, entry := range list {
+		if entry.Name() == "files.yml" || strings.HasSuffix(entry.Name(), ".golden") {
+			continue
+		}
+
+		t.Run(entry.Name(), func(t *testing.T) {
+			a := goheader.Analyzer{Settings: settings}
+
+			path := filepath.Join(dir, entry.Name())
+			require.True(t, goheader.SupportsFile(path))
+
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			fset := token.NewFileSet()
+
+			diag, err := a.AnalyzeFile(fset, path, content)
+			require.NoError(t, err)
+
+			golden, err := os.ReadFile(path + ".golden")
+			if os.IsNotExist(err) {
+				require.Nil(t, diag)
+				return
+			}
+			require.NoError(t, err)
+
+			require.NotNil(t, diag)
+			require.Len(t, diag.SuggestedFixes, 1)
+			require.Len(t, diag.SuggestedFixes[0].TextEdits, 1)
+
+			edit := diag.SuggestedFixes[0].TextEdits[0]
+			start, end := fset.Position(edit.Pos).Offset, fset.Position(edit.End).Offset
+
+			fixed := string(content[:start]) + string(edit.NewText) + string(content[end:])
+			require.Equal(t, string(golden), fixed)
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeFile_OutdatedYear(t *testing.T) {
+	settings := &goheader.Settings{
+		Template: "Copyright {{ .YEAR }} Acme\nSPDX-License-Identifier: MIT",
+		Now: func() time.Time {
+			return time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+		},
+	}
+	settings.SetDelimiters("", "")
+	settings.SetValues(nil)
+
+	a := goheader.Analyzer{Settings: settings}
+
+	content := "#!/bin/sh\n# Copyright 2024 Acme\n# SPDX-License-Identifier: MIT\n\necho\n"
+	fset := token.NewFileSet()
+
+	diag, err := a.AnalyzeFile(fset, "a.sh", []byte(content))
+	require.NoError(t, err)
+	require.NotNil(t, diag)
+	require.Equal(t, goheader.CategoryOutdatedYear, diag.Category)
+	require.Equal(t, "copyright year outdated: found 2024, expected 2026", diag.Message)
+
+	edit := diag.SuggestedFixes[0].TextEdits[0]
+	start, end := fset.Position(edit.Pos).Offset, fset.Position(edit.End).Offset
+	require.Equal(t, "#!/bin/sh\n# Copyright 2026 Acme\n# SPDX-License-Identifier: MIT\n\necho\n", content[:start]+string(edit.NewText)+content[end:])
+}
+
+func TestAnalyzer_AnalyzeFile_ShouldAddTemplateSourceOnce(t *testing.T) {
+	path := filepath.Join(analysistest.TestData(), "files", "files.yml")
+
+	cfg, err := goheader.Parse(path)
+	require.NoError(t, err)
+
+	settings := &goheader.Settings{}
+	require.NoError(t, cfg.FillSettings(settings))
+
+	a := goheader.Analyzer{Settings: settings}
+	fset := token.NewFileSet()
+
+	var related []token.Pos
+	for _, name := range []string{"a.sh", "b.sh"} {
+		diag, err := a.AnalyzeFile(fset, name, []byte("# Copyright 2026 Other\n"))
+		require.NoError(t, err)
+		require.NotNil(t, diag)
+		require.Len(t, diag.Related, 1)
+		related = append(related, diag.Related[0].Pos)
+	}
+
+	require.Equal(t, related[0], related[1])
+	require.Equal(t, path, fset.Position(related[0]).Filename)
+	require.Equal(t, 2, fset.Position(related[0]).Line)
+}
@@ -0,0 +1,475 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// fixer calculates texts of values for suggested fixes.
+type fixer struct {
+	values   map[string]Value
+	captured map[string]string
+	cache    map[string]string
+	visiting map[string]bool
+	err      error
+}
+
+func newFixer(values map[string]Value, captured map[string]string) *fixer {
+	return &fixer{
+		values:   values,
+		captured: captured,
+		cache:    make(map[string]string),
+		visiting: make(map[string]bool),
+	}
+}
+
+// validateFixes checks that fix texts of values match the values, otherwise fixed headers
+// would be reported again. Values that can't be calculated are reported by the analyzer.
+func validateFixes(values map[string]Value, year string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	vals, err := calculateValues(values, valuesKey{year: year})
+	if err != nil {
+		return nil
+	}
+
+	f := newFixer(vals, nil)
+
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		switch v := values[name].(type) {
+		case *RegexpValue:
+			if v.Fix == "" || v.Keep {
+				continue
+			}
+		case *ListValue:
+			if v.Fix == "" || v.Keep {
+				continue
+			}
+		default:
+			continue
+		}
+
+		text, err := f.value(name)
+		if err != nil {
+			return err
+		}
+
+		exp, err := regexp.Compile("^(?:" + vals[name].Get() + ")$")
+		if err == nil && !exp.MatchString(text) {
+			return fmt.Errorf("fix %q of value %v doesn't match %q", text, name, vals[name].Get())
+		}
+	}
+
+	return nil
+}
+
+// data returns template data that renders values as fix texts. The first error is stored in f.err.
+func (f *fixer) data() map[string]fmt.Stringer {
+	var result = make(map[string]fmt.Stringer, len(f.values))
+	for name := range f.values {
+		result[name] = fixValue{fixer: f, name: name}
+	}
+	return result
+}
+
+func (f *fixer) value(name string) (string, error) {
+	if v, ok := f.cache[name]; ok {
+		return v, nil
+	}
+
+	val := f.values[name]
+	if val == nil {
+		return "", fmt.Errorf("unknown value name %v", name)
+	}
+
+	if f.visiting[name] {
+		return "", fmt.Errorf("value %v refers to itself", name)
+	}
+	f.visiting[name] = true
+	defer delete(f.visiting, name)
+
+	var res string
+	var err error
+
+	switch v := val.(type) {
+	case *RegexpValue:
+		res, err = f.regexpValue(name, v)
+	case *YearRangeValue:
+		res, err = f.yearRangeValue(name, v)
+	case *ListValue:
+		if text, ok := f.captured[name]; ok {
+			res = text
+		} else {
+			res, err = expandValue(v.fix(), f.value)
+		}
+	default:
+		res, err = expandValue(val.Raw(), f.value)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	f.cache[name] = res
+
+	return res, nil
+}
+
+func (f *fixer) regexpValue(name string, v *RegexpValue) (string, error) {
+	if text, ok := f.captured[name]; ok {
+		return text, nil
+	}
+
+	if v.Fix != "" {
+		return expandValue(v.Fix, f.value)
+	}
+
+	pattern, err := expandValue(v.Raw(), func(name string) (string, error) {
+		text, err := f.value(name)
+		return regexp.QuoteMeta(text), err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if text, ok := literal(pattern); ok {
+		return text, nil
+	}
+
+	return "", fmt.Errorf("fixes are not supported for regexp value %v without `fix` or `keep`. See more details https://github.com/denis-tingaikin/go-header/issues/52", name)
+}
+
+// yearRangeValue updates the range of the existing header according to the policy.
+func (f *fixer) yearRangeValue(name string, v *YearRangeValue) (string, error) {
+	var raw = v.Fix
+	if raw == "" {
+		raw = v.Raw()
+	}
+
+	end, err := expandValue(raw, f.value)
+	if err != nil {
+		return "", err
+	}
+
+	captured := strings.TrimSpace(f.captured[name])
+
+	start, _, _ := strings.Cut(captured, "-")
+	start, _, _ = strings.Cut(start, ",")
+	start = strings.TrimSpace(start)
+
+	if start == "" || start > end {
+		return end, nil
+	}
+
+	switch v.Policy {
+	case ReplaceYearRange:
+		return end, nil
+	case FirstYearOnly:
+		return start, nil
+	case YearList:
+		years := strings.Split(captured, ",")
+		for i := range years {
+			years[i] = strings.TrimSpace(years[i])
+		}
+		if last := years[len(years)-1]; last != end && !strings.HasSuffix(last, "-"+end) {
+			years = append(years, end)
+		}
+		return strings.Join(years, ", "), nil
+	default:
+		if start == end {
+			return end, nil
+		}
+		return start + "-" + end, nil
+	}
+}
+
+type fixValue struct {
+	fixer *fixer
+	name  string
+}
+
+func (v fixValue) String() string {
+	text, err := v.fixer.value(v.name)
+	if err != nil && v.fixer.err == nil {
+		v.fixer.err = err
+	}
+	return text
+}
+
+// literal returns the text for the pattern if the pattern consists of literals only.
+// Unescaped dots are kept as is because they are commonly used in domain names.
+func literal(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	var sb strings.Builder
+	if !writeLiteral(&sb, re) {
+		return "", false
+	}
+
+	return sb.String(), true
+}
+
+func writeLiteral(sb *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+	case syntax.OpLiteral:
+		sb.WriteString(string(re.Rune))
+	case syntax.OpAnyCharNotNL:
+		sb.WriteByte('.')
+	case syntax.OpCapture:
+		return writeLiteral(sb, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writeLiteral(sb, sub) {
+				return false
+			}
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// yearsPattern matches a year, a range or a list of years in an existing header.
+const yearsPattern = `\d{4}(?:(?:\s*-\s*|,\s*)\d{4})*`
+
+// capture returns texts matched by values in the existing header. It tries the whole header first
+// and then each line separately, so values from unchanged lines are kept even if other lines differ.
+// Values are captured only if they are still valid, except year values and values with Keep.
+func (a *Analyzer) capture(tmplText, header string, vals targetValues) map[string]string {
+	var result = make(map[string]string)
+
+	if header == "" {
+		return result
+	}
+
+	a.captureText(tmplText, header, vals, result)
+
+	tmplLines := strings.Split(tmplText, "\n")
+	headerLines := strings.Split(header, "\n")
+
+	if len(tmplLines) > 1 {
+		for i := 0; i < len(tmplLines) && i < len(headerLines); i++ {
+			a.captureText(strings.TrimSpace(tmplLines[i]), strings.TrimSpace(headerLines[i]), vals, result)
+		}
+	}
+
+	return result
+}
+
+func (a *Analyzer) captureText(tmplText, text string, vals targetValues, result map[string]string) {
+	// The strict attempt keeps the structure of nested values, the loose one matches values with any text.
+	for _, kind := range []string{"capture", "capture loose"} {
+		c := newCapturer(vals.vars, kind == "capture loose")
+
+		capture, err := a.matchers.getCapture(tmplText, kind, vals, func() (*captureExp, error) {
+			exp, err := a.compileExact(tmplText, c.data())
+			if err != nil {
+				return nil, err
+			}
+			if c.err != nil {
+				return nil, c.err
+			}
+			return &captureExp{exp: exp, names: c.names}, nil
+		})
+		if err != nil {
+			continue
+		}
+
+		match := capture.exp.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+
+		for i, name := range capture.names {
+			if _, ok := result[name]; ok {
+				continue
+			}
+
+			captured := match[capture.exp.SubexpIndex(fmt.Sprintf("g%v", i))]
+			if c.valid(name, captured) {
+				result[name] = captured
+			}
+		}
+	}
+}
+
+// compileExact compiles the template rendered with data into a regexp matching the whole text.
+func (a *Analyzer) compileExact(tmplText string, data any) (*regexp.Regexp, error) {
+	expr, err := a.expression(tmplText, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+// expression renders the template with data into a regexp. The text of the template is quoted,
+// blocks become regexp groups.
+func (a *Analyzer) expression(tmplText string, data any) (string, error) {
+	text, err := a.rewriteBlocks(a.quoteMeta(tmplText), regexpSection)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New("header").Delims(a.Settings.LeftDelim, a.Settings.RightDelim).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// capturer renders values as named regexp groups. Group names are indexes in names.
+type capturer struct {
+	values   map[string]Value
+	loose    bool
+	names    []string
+	visiting map[string]bool
+	err      error
+}
+
+func newCapturer(values map[string]Value, loose bool) *capturer {
+	return &capturer{
+		values:   values,
+		loose:    loose,
+		visiting: make(map[string]bool),
+	}
+}
+
+func (c *capturer) data() map[string]fmt.Stringer {
+	var result = make(map[string]fmt.Stringer, len(c.values))
+	for name := range c.values {
+		result[name] = captureGroup{capturer: c, name: name}
+	}
+	return result
+}
+
+func (c *capturer) group(name string) (string, error) {
+	v := c.values[name]
+	if v == nil {
+		return "", fmt.Errorf("unknown value name %v", name)
+	}
+
+	if c.visiting[name] {
+		return "", fmt.Errorf("value %v refers to itself", name)
+	}
+	c.visiting[name] = true
+	defer delete(c.visiting, name)
+
+	index := len(c.names)
+	c.names = append(c.names, name)
+
+	pattern, err := c.pattern(name, v)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("(?P<g%v>%v)", index, pattern), nil
+}
+
+func (c *capturer) pattern(name string, v Value) (string, error) {
+	if keepValue(v) {
+		return `(?s:.*?)`, nil
+	}
+
+	if isYearValue(name, v) {
+		return yearsPattern, nil
+	}
+
+	if c.loose {
+		return `(?s:.*?)`, nil
+	}
+
+	switch v.(type) {
+	case *RegexpValue, *ConstValue:
+		return expandValue(v.Raw(), c.group)
+	default:
+		return v.Get(), nil
+	}
+}
+
+// valid reports whether the captured text can be reused by fixes.
+func (c *capturer) valid(name, text string) bool {
+	v := c.values[name]
+
+	if keepValue(v) {
+		return true
+	}
+
+	// Ranges are updated by fixes, other year values are only valid if they are up to date.
+	if _, ok := v.(*YearRangeValue); ok {
+		return true
+	}
+
+	exp, err := regexp.Compile("^(?:" + v.Get() + ")$")
+
+	return err == nil && exp.MatchString(text)
+}
+
+type captureGroup struct {
+	capturer *capturer
+	name     string
+}
+
+func (g captureGroup) String() string {
+	pattern, err := g.capturer.group(g.name)
+	if err != nil && g.capturer.err == nil {
+		g.capturer.err = err
+	}
+	return pattern
+}
+
+// keepValue reports whether fixes reuse the text matched by the value whatever it is.
+func keepValue(v Value) bool {
+	switch v := v.(type) {
+	case *RegexpValue:
+		return v.Keep
+	case *ListValue:
+		return v.Keep
+	}
+	return false
+}
+
+// isYearValue reports whether the value is a built-in year value. Such values are captured with any years,
+// so fixes can keep the first year of the existing header.
+func isYearValue(name string, v Value) bool {
+	if _, ok := v.(*YearRangeValue); ok {
+		return true
+	}
+
+	return name == "YEAR" || name == "MOD_YEAR"
+}
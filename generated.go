@@ -0,0 +1,73 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+	"strings"
+)
+
+// GeneratedPolicy defines how generated files are checked
+type GeneratedPolicy string
+
+const (
+	// CheckGenerated requires the header on top of generated files, before the generated code marker.
+	CheckGenerated GeneratedPolicy = "check"
+	// SkipGenerated disables checking of generated files.
+	SkipGenerated GeneratedPolicy = "skip"
+	// CheckAfterMarker requires the header after the comment with the generated code marker.
+	CheckAfterMarker GeneratedPolicy = "check-after-marker"
+)
+
+// ParseGeneratedPolicy returns the policy by its name. Empty name means CheckGenerated.
+func ParseGeneratedPolicy(s string) (GeneratedPolicy, error) {
+	switch p := GeneratedPolicy(s); p {
+	case "":
+		return CheckGenerated, nil
+	case CheckGenerated, SkipGenerated, CheckAfterMarker:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown generated policy %q", s)
+	}
+}
+
+// generatedMarker is the same as ast.IsGenerated uses, see https://go.dev/s/generatedcode.
+var generatedMarker = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// generatedMarkerGroup returns the comment group with the generated code marker before the package clause.
+func generatedMarkerGroup(file *ast.File) *ast.CommentGroup {
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if generatedMarker.MatchString(c.Text) {
+				return group
+			}
+		}
+	}
+
+	return nil
+}
+
+// isCgoMarker reports whether the group is the marker of files generated by cgo. The original
+// file content including the header follows it, so the header is always checked after the marker.
+func isCgoMarker(group *ast.CommentGroup) bool {
+	return strings.HasPrefix(group.Text(), "Code generated by cmd/cgo")
+}
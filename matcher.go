@@ -0,0 +1,341 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MatchMode defines how the template must cover the header
+type MatchMode string
+
+const (
+	// MatchContains accepts headers with any text before and after the template. It is the default.
+	MatchContains MatchMode = "contains"
+	// MatchPrefix accepts headers with text after the template only.
+	MatchPrefix MatchMode = "prefix"
+	// MatchExact requires the template to cover the whole header.
+	MatchExact MatchMode = "exact"
+)
+
+// ParseMatchMode returns the match mode by its name. Empty name means MatchContains.
+func ParseMatchMode(s string) (MatchMode, error) {
+	switch m := MatchMode(s); m {
+	case "":
+		return MatchContains, nil
+	case MatchContains, MatchPrefix, MatchExact:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown match mode %q", s)
+	}
+}
+
+// anchor returns the regexp matching the header according to the mode.
+func anchor(expr string, mode MatchMode) string {
+	switch mode {
+	case MatchExact:
+		return "^(?:" + expr + ")$"
+	case MatchPrefix:
+		return "^(?:" + expr + ")"
+	default:
+		return expr
+	}
+}
+
+// MatcherType defines how headers are compared with templates
+type MatcherType string
+
+const (
+	// RegexpMatcher renders the whole template into one regexp. It is the default.
+	RegexpMatcher MatcherType = "regexp"
+	// LineMatcher compares the header with the template line by line.
+	LineMatcher MatcherType = "lines"
+)
+
+// ParseMatcherType returns the matcher type by its name. Empty name means RegexpMatcher.
+func ParseMatcherType(s string) (MatcherType, error) {
+	switch t := MatcherType(s); t {
+	case "":
+		return RegexpMatcher, nil
+	case RegexpMatcher, LineMatcher:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown matcher type %q", s)
+	}
+}
+
+// Whitespace defines how the line matcher normalizes whitespace of template and header lines
+type Whitespace string
+
+const (
+	// ExactWhitespace compares lines as is.
+	ExactWhitespace Whitespace = "exact"
+	// TrimWhitespace ignores leading and trailing whitespace including `\r` of CRLF line endings. It is the default.
+	TrimWhitespace Whitespace = "trim"
+	// CollapseWhitespace trims lines and treats runs of spaces and tabs as a single space.
+	CollapseWhitespace Whitespace = "collapse"
+)
+
+// ParseWhitespace returns the whitespace mode by its name. Empty name means TrimWhitespace.
+func ParseWhitespace(s string) (Whitespace, error) {
+	switch w := Whitespace(s); w {
+	case "":
+		return TrimWhitespace, nil
+	case ExactWhitespace, TrimWhitespace, CollapseWhitespace:
+		return w, nil
+	default:
+		return "", fmt.Errorf("unknown whitespace mode %q", s)
+	}
+}
+
+// MatcherSettings configures how headers are compared with templates.
+// Fields except Type are used by the line matcher only.
+type MatcherSettings struct {
+	Type       MatcherType
+	Whitespace Whitespace
+	// OptionalLines are template lines that can be missing in headers, e.g. "Modified by {{ .AUTHOR }}".
+	// They are compared with template lines after whitespace normalization.
+	OptionalLines []string
+}
+
+// normalizeLine normalizes whitespace of a template or a header line. Lines are trimmed if
+// the line matcher is not used, like the regexp matcher ignores surrounding whitespace of lines.
+func (a *Analyzer) normalizeLine(line string) string {
+	m := a.Settings.Matcher
+	if m.Type != LineMatcher {
+		return strings.TrimSpace(line)
+	}
+
+	switch m.Whitespace {
+	case ExactWhitespace:
+		return line
+	case CollapseWhitespace:
+		return strings.Join(strings.Fields(line), " ")
+	default:
+		return strings.TrimSpace(line)
+	}
+}
+
+// match returns the offsets of the part of the header matched by the template or nil.
+func (a *Analyzer) match(tmplText, header string, vals targetValues) ([]int, error) {
+	if a.Settings.Matcher.Type == LineMatcher {
+		return a.matchLines(tmplText, header, vals)
+	}
+
+	mode := a.Settings.Match
+
+	if mode == "" || mode == MatchContains {
+		exp, err := a.compile(tmplText, vals)
+		if err != nil {
+			return nil, err
+		}
+		return exp.FindStringIndex(header), nil
+	}
+
+	exp, err := a.matchers.getExp(tmplText, string(mode), vals, func() (*regexp.Regexp, error) {
+		expr, err := a.expression(tmplText, vals.vars)
+		if err != nil {
+			return nil, err
+		}
+		return regexp.Compile(anchor(expr, mode))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return exp.FindStringIndex(header), nil
+}
+
+// lineNode is a template line or a group of lines from a block for the line matcher.
+type lineNode struct {
+	// exp is nil for groups, text is the template line of exp.
+	exp              *regexp.Regexp
+	text             string
+	optional, repeat bool
+	children         []lineNode
+	// line is the index of the first template line of the node, it is -1 for lines of blocks.
+	// lines is the number of template lines of the node.
+	line, lines int
+}
+
+// lineNodes splits the template into lines. Blocks must be in one line or contain whole lines.
+func (a *Analyzer) lineNodes(tmplText string, vals targetValues) ([]lineNode, error) {
+	var groups []lineNode
+
+	text, err := a.rewriteBlocks(tmplText, func(s section) (string, error) {
+		if !s.lines {
+			if strings.Contains(s.text, "\n") {
+				return "", fmt.Errorf("%v block must be in one line or contain whole lines", s.kind)
+			}
+			return s.raw, nil
+		}
+
+		children, err := a.parseLineNodes(s.text, vals, groups)
+		if err != nil {
+			return "", err
+		}
+
+		groups = append(groups, lineNode{optional: true, repeat: s.kind == repeatBlock, children: children, line: -1, lines: s.breaks + 1})
+
+		// Groups are referred by markers on separate lines.
+		return fmt.Sprintf("%v\x00%v%v", s.before, len(groups)-1, s.after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := a.parseLineNodes(text, vals, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	var line int
+	for i := range nodes {
+		nodes[i].line = line
+		line += nodes[i].lines
+	}
+
+	return nodes, nil
+}
+
+func (a *Analyzer) parseLineNodes(text string, vals targetValues, groups []lineNode) ([]lineNode, error) {
+	var result []lineNode
+
+	for _, line := range strings.Split(text, "\n") {
+		if index, ok := strings.CutPrefix(line, "\x00"); ok {
+			var i int
+			_, _ = fmt.Sscan(index, &i)
+			result = append(result, groups[i])
+			continue
+		}
+
+		exp, err := a.compileLine(line, vals)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, lineNode{
+			exp:  exp,
+			text: line,
+			optional: slices.ContainsFunc(a.Settings.Matcher.OptionalLines, func(s string) bool {
+				return a.normalizeLine(s) == a.normalizeLine(line)
+			}),
+			line:  -1,
+			lines: 1,
+		})
+	}
+
+	return result, nil
+}
+
+// lineWalk matches template line nodes with header lines.
+type lineWalk struct {
+	a     *Analyzer
+	lines []string
+	// fail is called for template lines that don't match the header line j, if set.
+	fail func(n lineNode, j int)
+}
+
+// match returns the index after the last header line matched by nodes from the line j
+// and by the continuation next or -1.
+func (w *lineWalk) match(nodes []lineNode, j int, next func(j int) int) int {
+	if len(nodes) == 0 {
+		return next(j)
+	}
+
+	n := nodes[0]
+	rest := func(j int) int {
+		return w.match(nodes[1:], j, next)
+	}
+
+	switch {
+	case n.exp != nil:
+		if j < len(w.lines) && n.exp.MatchString(w.a.normalizeLine(w.lines[j])) {
+			if e := rest(j + 1); e >= 0 {
+				return e
+			}
+		} else if w.fail != nil {
+			w.fail(n, j)
+		}
+	case n.repeat:
+		var repeat func(j int) int
+		repeat = func(j int) int {
+			e := w.match(n.children, j, func(k int) int {
+				if k == j {
+					return -1
+				}
+				return repeat(k)
+			})
+			if e >= 0 {
+				return e
+			}
+			return rest(j)
+		}
+		return repeat(j)
+	default:
+		if e := w.match(n.children, j, rest); e >= 0 {
+			return e
+		}
+	}
+
+	if n.optional {
+		return rest(j)
+	}
+
+	return -1
+}
+
+// matchEnd returns the continuation that accepts the end of a match in the header of n lines.
+func (a *Analyzer) matchEnd(n int) func(j int) int {
+	return func(j int) int {
+		if a.Settings.Match == MatchExact && j != n {
+			return -1
+		}
+		return j
+	}
+}
+
+// matchLines matches template lines with consecutive header lines. Optional lines can be skipped,
+// lines of repeat blocks can be repeated. A value can't span several lines.
+func (a *Analyzer) matchLines(tmplText, header string, vals targetValues) ([]int, error) {
+	nodes, err := a.lineNodes(tmplText, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	headerLines := strings.Split(header, "\n")
+	mode := a.Settings.Match
+	w := &lineWalk{a: a, lines: headerLines}
+	last := a.matchEnd(len(headerLines))
+
+	var offset int
+	for start, line := range headerLines {
+		if e := w.match(nodes, start, last); e >= 0 {
+			matched := strings.Join(headerLines[start:e], "\n")
+			return []int{offset, offset + len(matched)}, nil
+		}
+		if mode == MatchPrefix || mode == MatchExact {
+			break
+		}
+		offset += len(line) + 1
+	}
+
+	return nil, nil
+}
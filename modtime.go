@@ -0,0 +1,528 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModTimeSource describes where the modification time of a file comes from
+type ModTimeSource string
+
+const (
+	// ModTimeFromGit means the time of the last commit of the file.
+	ModTimeFromGit ModTimeSource = "git"
+	// ModTimeFromFileSystem means the file system modification time. It is used for files with uncommitted
+	// changes and for files outside of git repositories.
+	ModTimeFromFileSystem ModTimeSource = "filesystem"
+	// ModTimeFromNow means the current time. It is used for files that are not committed yet.
+	ModTimeFromNow ModTimeSource = "now"
+)
+
+// errNotTracked means that the file is not committed to the repository.
+var errNotTracked = errors.New("file is not tracked by git")
+
+// modTimes resolves modification times of files. Each git repository is found from the file location
+// and its history is read once and shared between workers. The history is not refreshed for the lifetime
+// of the Analyzer, so drivers that keep running between checks must create a new analyzer with New
+// to see new commits and changes.
+type modTimes struct {
+	mu        sync.Mutex
+	roots     map[string]string
+	histories map[string]*historyLoader
+}
+
+type historyLoader struct {
+	once    sync.Once
+	history *gitHistory
+}
+
+// fileTimes are modification and creation times of a file.
+type fileTimes struct {
+	modified time.Time
+	source   ModTimeSource
+	// created is the time of the first commit of the file following renames. It is zero if unknown.
+	created time.Time
+}
+
+// get returns times of the last and the first commits of the file. Files with uncommitted changes and files
+// outside of a git repository use the file system modification time.
+func (m *modTimes) get(path string, dateSource DateSource) (fileTimes, error) {
+	var res fileTimes
+
+	if history := m.history(path); history != nil {
+		if name, err := history.relative(path); err == nil {
+			res.created = history.created[name].get(dateSource)
+
+			dates, ok := history.modified[name]
+			if !ok {
+				res.source = ModTimeFromNow
+				return res, errNotTracked
+			}
+
+			if !history.dirty[name] {
+				res.modified, res.source = dates.get(dateSource), ModTimeFromGit
+				return res, nil
+			}
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return res, err
+	}
+
+	res.modified, res.source = info.ModTime(), ModTimeFromFileSystem
+
+	return res, nil
+}
+
+// history returns the history of the repository containing the path or nil.
+func (m *modTimes) history(path string) *gitHistory {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	if m.roots == nil {
+		m.roots = make(map[string]string)
+		m.histories = make(map[string]*historyLoader)
+	}
+	root, ok := m.roots[dir]
+	m.mu.Unlock()
+
+	if !ok {
+		// Submodules and worktrees are resolved by git itself since it runs in the file directory.
+		out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+		if err == nil {
+			root = strings.TrimSpace(string(out))
+		}
+
+		m.mu.Lock()
+		m.roots[dir] = root
+		m.mu.Unlock()
+	}
+
+	if root == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	loader, ok := m.histories[root]
+	if !ok {
+		loader = new(historyLoader)
+		m.histories[root] = loader
+	}
+	m.mu.Unlock()
+
+	loader.once.Do(func() {
+		loader.history, _ = loadGitHistory(root)
+	})
+
+	return loader.history
+}
+
+// DateSource defines which date of git commits is used
+type DateSource string
+
+const (
+	// CommitterDate is the date when the commit was made. It is the default.
+	CommitterDate DateSource = "committer"
+	// AuthorDate is the date when the change was originally written.
+	AuthorDate DateSource = "author"
+)
+
+// ParseDateSource returns the date source by its name. Empty name means CommitterDate.
+func ParseDateSource(s string) (DateSource, error) {
+	switch d := DateSource(s); d {
+	case "":
+		return CommitterDate, nil
+	case CommitterDate, AuthorDate:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unknown date source %q", s)
+	}
+}
+
+type commitDates struct {
+	committer, author time.Time
+}
+
+func (d commitDates) get(source DateSource) time.Time {
+	if source == AuthorDate {
+		return d.author
+	}
+	return d.committer
+}
+
+// gitHistory is an index of commit dates of files built by a single walk over the git log.
+type gitHistory struct {
+	root string
+	// modified contains dates of the last commits of paths.
+	modified map[string]commitDates
+	// created contains dates of the first commits of files by their current names.
+	created map[string]commitDates
+	dirty   map[string]bool
+}
+
+func loadGitHistory(root string) (*gitHistory, error) {
+	h := &gitHistory{
+		root:     root,
+		modified: make(map[string]commitDates),
+		created:  make(map[string]commitDates),
+		dirty:    make(map[string]bool),
+	}
+
+	diff, err := exec.Command("git", "-C", h.root, "diff", "--name-only", "-z").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range strings.Split(string(diff), "\x00") {
+		if name != "" {
+			h.dirty[name] = true
+		}
+	}
+
+	// Paths changed by merges relative to their first parents, see historyWalk.
+	firstParent := make(map[string]map[string]bool)
+
+	err = h.streamLog([]string{"--merges", "--diff-merges=first-parent", "--name-only", "--format=%x1e%H"}, func(scanner *bufio.Scanner) error {
+		var changed map[string]bool
+		for scanner.Scan() {
+			token := strings.TrimPrefix(scanner.Text(), "\n")
+			if hash, ok := strings.CutPrefix(token, "\x1e"); ok {
+				changed = make(map[string]bool)
+				firstParent[hash] = changed
+			} else if token != "" && changed != nil {
+				changed[token] = true
+			}
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w := &historyWalk{
+		history:     h,
+		firstParent: firstParent,
+		pending:     make(map[string]*pathSet),
+	}
+
+	// Merges are listed with the paths that differ from all of their parents. The date order lists children
+	// before their parents, so the walk can follow the history as `git log -- path` does.
+	err = h.streamLog([]string{"--date-order", "--diff-merges=dense-combined", "--name-status", "-M", "--format=%x1e%H%x1f%P%x1f%cI%x1f%aI"}, h.readLog(w))
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// streamLog runs `git log -z` with args and reads its output with read. The log of a big repository is large,
+// so it's read as a stream.
+func (h *gitHistory) streamLog(args []string, read func(*bufio.Scanner) error) error {
+	cmd := exec.Command("git", append([]string{"-C", h.root, "log", "-z"}, args...)...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, 1<<20)
+	scanner.Split(scanNulTerminated)
+
+	if err := read(scanner); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+
+	return cmd.Wait()
+}
+
+// logCommit is a commit read from the log.
+type logCommit struct {
+	hash    string
+	parents []string
+	dates   commitDates
+	// changed contains paths changed by the commit. Both names of renamed files are included.
+	changed []string
+}
+
+// readLog returns a reader of the log with commits separated by \x1e, each followed by statuses and paths
+// of the changed files, all separated by \x00. Renames in commits with a single parent have both old and new paths.
+func (h *gitHistory) readLog(w *historyWalk) func(*bufio.Scanner) error {
+	return func(scanner *bufio.Scanner) error {
+		var c *logCommit
+		// renamed maps old names to the current ones. The log goes from the newest commits to the oldest.
+		var renamed = make(map[string]string)
+
+		current := func(name string) string {
+			if newName, ok := renamed[name]; ok {
+				return newName
+			}
+			return name
+		}
+
+		for scanner.Scan() {
+			token := strings.TrimPrefix(scanner.Text(), "\n")
+
+			if header, ok := strings.CutPrefix(token, "\x1e"); ok {
+				if c != nil {
+					if err := w.visit(c); err != nil {
+						return err
+					}
+				}
+
+				fields := strings.Split(header, "\x1f")
+				if len(fields) != 4 {
+					return fmt.Errorf("unexpected git log header %q", header)
+				}
+
+				c = &logCommit{hash: fields[0], parents: strings.Fields(fields[1])}
+
+				var err error
+				if c.dates.committer, err = time.Parse(time.RFC3339, fields[2]); err != nil {
+					return err
+				}
+				if c.dates.author, err = time.Parse(time.RFC3339, fields[3]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if token == "" || c == nil || !scanner.Scan() {
+				continue
+			}
+
+			name := scanner.Text()
+
+			// Merges are listed with the combined diff, which has only the resulting paths.
+			if len(c.parents) > 1 {
+				c.changed = append(c.changed, name)
+				continue
+			}
+
+			if token[0] == 'R' && scanner.Scan() {
+				oldName, newName := name, scanner.Text()
+				renamed[oldName] = current(newName)
+				c.changed = append(c.changed, oldName)
+				name = newName
+			}
+
+			c.changed = append(c.changed, name)
+			h.created[current(name)] = c.dates
+		}
+
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+
+		if c != nil {
+			return w.visit(c)
+		}
+
+		return nil
+	}
+}
+
+// historyWalk finds the last commits of paths the way `git log -1 -- path` does with the default history
+// simplification: a merge that is the same as one of its parents for the path is followed to that parent only,
+// so changes of branches discarded by merges are ignored, and a merge that differs from all of its parents
+// is the last commit itself.
+type historyWalk struct {
+	history *gitHistory
+	// firstParent contains paths changed by merges relative to their first parents.
+	firstParent map[string]map[string]bool
+	// pending contains paths for which commits are reached from the head. The paths are not resolved yet.
+	pending map[string]*pathSet
+	started bool
+}
+
+func (w *historyWalk) visit(c *logCommit) error {
+	reach := w.pending[c.hash]
+	delete(w.pending, c.hash)
+
+	if !w.started {
+		w.started = true
+		reach = &pathSet{all: true, paths: make(map[string]bool)}
+	}
+
+	if reach == nil {
+		return nil
+	}
+
+	for _, name := range c.changed {
+		if _, ok := w.history.modified[name]; !ok && reach.contains(name) {
+			w.history.modified[name] = c.dates
+		}
+	}
+
+	switch len(c.parents) {
+	case 0:
+		return nil
+	case 1:
+		// Resolved paths are skipped by the parents, so the set is passed as is.
+		w.add(c.parents[0], reach)
+		return nil
+	}
+
+	// Paths that are the same as in the first parent follow it, other unresolved paths follow
+	// the first parent they are the same as.
+	changed := w.firstParent[c.hash]
+
+	var first = &pathSet{all: reach.all, paths: make(map[string]bool)}
+	for name := range reach.paths {
+		if _, ok := w.history.modified[name]; !ok && (reach.all || !changed[name]) {
+			first.paths[name] = true
+		}
+	}
+
+	var rest = make(map[string]bool)
+	for name := range changed {
+		if _, ok := w.history.modified[name]; !ok && reach.contains(name) {
+			rest[name] = true
+			if first.all {
+				first.paths[name] = true
+			}
+		}
+	}
+
+	w.add(c.parents[0], first)
+
+	for i, parent := range c.parents[1:] {
+		if len(rest) == 0 {
+			break
+		}
+
+		next := &pathSet{paths: rest}
+
+		// Paths of octopus merges are checked against each parent, the last parent takes the rest.
+		if i < len(c.parents)-2 {
+			diff, err := exec.Command("git", "-C", w.history.root, "diff-tree", "-r", "--name-only", "-z", parent, c.hash).Output()
+			if err != nil {
+				return err
+			}
+
+			next.paths = make(map[string]bool)
+			for _, name := range strings.Split(string(diff), "\x00") {
+				if rest[name] {
+					delete(rest, name)
+					next.paths[name] = true
+				}
+			}
+			next.paths, rest = rest, next.paths
+		}
+
+		w.add(parent, next)
+	}
+
+	return nil
+}
+
+// add adds paths reached from a child to the commit.
+func (w *historyWalk) add(hash string, set *pathSet) {
+	if existing := w.pending[hash]; existing != nil {
+		set = existing.union(set)
+	}
+	w.pending[hash] = set
+}
+
+// pathSet is a set of paths. If all is true, it contains all paths except the ones in paths.
+type pathSet struct {
+	all   bool
+	paths map[string]bool
+}
+
+func (s *pathSet) contains(name string) bool {
+	return s.paths[name] != s.all
+}
+
+// union returns the union of the sets. The sets are modified.
+func (s *pathSet) union(other *pathSet) *pathSet {
+	switch {
+	case s.all && other.all:
+		for name := range s.paths {
+			if !other.paths[name] {
+				delete(s.paths, name)
+			}
+		}
+		return s
+	case s.all:
+		for name := range other.paths {
+			delete(s.paths, name)
+		}
+		return s
+	case other.all:
+		return other.union(s)
+	default:
+		for name := range other.paths {
+			s.paths[name] = true
+		}
+		return s
+	}
+}
+
+// scanNulTerminated is a bufio.SplitFunc for tokens terminated by \x00.
+func scanNulTerminated(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (h *gitHistory) relative(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	rel, err := filepath.Rel(h.root, path)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(rel, "..") {
+		return "", errors.New("file is outside of the repository")
+	}
+
+	return filepath.ToSlash(rel), nil
+}
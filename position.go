@@ -0,0 +1,98 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkPosition reports a header which is a part of the package doc comment, so godoc shows it.
+// The header is in the beginning of the comment group, text is the plain text of the header comment
+// and headerEnd is the offset of the end of the matched header in it.
+func checkPosition(file *ast.File, comment *ast.CommentGroup, text string, headerEnd int) *analysis.Diagnostic {
+	if comment != file.Doc || headerEnd < 0 {
+		return nil
+	}
+
+	// Index of the last line of the header in text.
+	lastLine := strings.Count(strings.TrimRight(text[:headerEnd], " \t\n"), "\n")
+	merged := strings.TrimSpace(text[headerEnd:]) != ""
+
+	first := comment.List[0]
+
+	if strings.HasPrefix(first.Text, "/*") {
+		switch {
+		case merged:
+			// Close the comment after the header and open a new one for the doc.
+			rest := first.Text[lineEnd(first.Text, lineOffset(first.Text, lastLine))-1:]
+			closing := "*/"
+			if strings.HasPrefix(strings.TrimSpace(rest), "*") {
+				closing = " */"
+			}
+			pos := first.Pos() + token.Pos(len(first.Text)-len(rest))
+			return positionDiagnostic(mergedHeaderMessage, pos, "\n"+closing+"\n\n/*")
+		case len(comment.List) > 1:
+			return positionDiagnostic(mergedHeaderMessage, first.End(), "\n")
+		default:
+			return positionDiagnostic(attachedHeaderMessage, comment.End(), "\n")
+		}
+	}
+
+	lines := lineComments(comment)
+
+	if merged && lastLine < len(lines)-1 {
+		return positionDiagnostic(mergedHeaderMessage, lines[lastLine].End(), "\n")
+	}
+
+	return positionDiagnostic(attachedHeaderMessage, comment.End(), "\n")
+}
+
+const (
+	attachedHeaderMessage = "header must be separated from the package clause by a blank line"
+	mergedHeaderMessage   = "header is merged with the package doc comment"
+)
+
+func positionDiagnostic(message string, pos token.Pos, insert string) *analysis.Diagnostic {
+	return &analysis.Diagnostic{
+		Pos:      pos,
+		End:      pos,
+		Message:  message,
+		Category: CategoryHeaderPosition,
+		SuggestedFixes: []analysis.SuggestedFix{{
+			TextEdits: []analysis.TextEdit{{
+				Pos:     pos,
+				End:     pos,
+				NewText: []byte(insert),
+			}},
+		}},
+	}
+}
+
+// lineOffset returns the offset of the start of the line with the index.
+func lineOffset(text string, line int) int {
+	var offset int
+
+	for ; line > 0 && offset < len(text); line-- {
+		offset = lineEnd(text, offset)
+	}
+
+	return offset
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Rule overrides templates and values for files matching any of Paths
+type Rule struct {
+	// Paths are glob patterns relative to the working directory. `**` matches any number of directories.
+	// A pattern without a slash matches the file name in any directory.
+	Paths []string
+	// Skip disables checking of matched files.
+	Skip bool
+	// Templates replace Settings templates for matched files if not empty.
+	Templates []Template
+	// Values replace Settings values for matched files if not nil.
+	Values map[string]Value
+}
+
+// GetRule returns the most specific rule matching the path or nil. If several rules
+// are equally specific the last one wins.
+func (c *Settings) GetRule(filename string) *Rule {
+	if len(c.Rules) == 0 {
+		return nil
+	}
+
+	name := relativePath(filename)
+
+	var result *Rule
+	var bestScore = -1
+
+	for i := range c.Rules {
+		for _, pattern := range c.Rules[i].Paths {
+			if !matchGlob(pattern, name) {
+				continue
+			}
+			if score := globSpecificity(pattern); score >= bestScore {
+				bestScore = score
+				result = &c.Rules[i]
+			}
+		}
+	}
+
+	return result
+}
+
+func relativePath(filename string) string {
+	if filepath.IsAbs(filename) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, filename); err == nil && !strings.HasPrefix(rel, "..") {
+				filename = rel
+			}
+		}
+	}
+
+	return filepath.ToSlash(filepath.Clean(filename))
+}
+
+// matchGlob reports whether name matches the slash separated pattern.
+func matchGlob(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// validateGlob returns path.ErrBadPattern if the pattern is malformed. Such patterns never match.
+func validateGlob(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// globSpecificity returns the number of literal characters in the pattern.
+func globSpecificity(pattern string) int {
+	var result int
+	var inClass bool
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		case c == '\\':
+			i++
+			result++
+		case c != '*' && c != '?':
+			result++
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateSource is the location of a template text. Diagnostics refer to it.
+type TemplateSource struct {
+	// Path is the config file or the template file.
+	Path string
+	// Line is the line of the first template line in the file, starting from 1.
+	Line int
+}
+
+// templateFileSource returns the source of a template read from the file by readTemplate.
+func templateFileSource(path string) TemplateSource {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return TemplateSource{}
+	}
+
+	leading := len(b) - len(strings.TrimLeft(string(b), " \t\r\n"))
+
+	return TemplateSource{Path: path, Line: 1 + strings.Count(string(b[:leading]), "\n")}
+}
+
+// yamlLines records lines of scalar values of the node by their paths like `templates.0.template`.
+// The line of a block scalar is the line of its first content line.
+func yamlLines(node *yaml.Node, path string, lines map[string]int) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			yamlLines(n, path, lines)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			yamlLines(node.Content[i+1], joinYAMLPath(path, node.Content[i].Value), lines)
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			yamlLines(n, joinYAMLPath(path, fmt.Sprint(i)), lines)
+		}
+	case yaml.ScalarNode:
+		lines[path] = node.Line
+		if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			lines[path]++
+		}
+	}
+}
+
+func joinYAMLPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sourceFiles adds template sources to a file set, so related information can point at them.
+// It is created for a pass or a file and doesn't outlive the file set.
+type sourceFiles struct {
+	fset  *token.FileSet
+	mu    sync.Mutex
+	files map[string]*token.File
+}
+
+func newSourceFiles(fset *token.FileSet) *sourceFiles {
+	return &sourceFiles{fset: fset, files: make(map[string]*token.File)}
+}
+
+func (s *sourceFiles) get(path string) *token.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, ok := s.files[path]; ok {
+		return f
+	}
+
+	// The file set may be shared by passes and files, so the source can be added already.
+	var f *token.File
+	s.fset.Iterate(func(file *token.File) bool {
+		if file.Name() == path {
+			f = file
+		}
+		return f == nil
+	})
+
+	if f == nil {
+		if content, err := os.ReadFile(path); err == nil {
+			f = s.fset.AddFile(path, -1, len(content))
+			f.SetLinesForContent(content)
+		}
+	}
+	s.files[path] = f
+
+	return f
+}
+
+// templateRelated points at the line of the template with the index.
+func templateRelated(sources *sourceFiles, tmpl Template, line int) []analysis.RelatedInformation {
+	if sources == nil || tmpl.Source.Path == "" {
+		return nil
+	}
+
+	f := sources.get(tmpl.Source.Path)
+	if f == nil {
+		return nil
+	}
+
+	l := tmpl.Source.Line + line
+	if l < 1 || l > f.LineCount() {
+		return nil
+	}
+
+	pos := f.LineStart(l)
+
+	return []analysis.RelatedInformation{{
+		Pos:     pos,
+		End:     pos,
+		Message: fmt.Sprintf("template %q", tmpl.Name),
+	}}
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// CommentStyle describes how a header is written as a comment. Custom styles can be registered
+// in Settings.CommentStyles, they are detected before the built-in ones.
+type CommentStyle interface {
+	// Name identifies the style in the config.
+	Name() string
+	// Detect reports whether the comment is written in the style. The comment is passed as is, with markers.
+	Detect(comment string) bool
+	// Strip returns the plain header text of the comment.
+	Strip(comment string) string
+	// Render returns the plain header text as a comment without a trailing line break.
+	Render(text string) string
+}
+
+// Built-in comment styles.
+var (
+	// LineComment is `// text`.
+	LineComment = NewLineCommentStyle("line", "// ")
+	// BlockComment is `/* text */`.
+	BlockComment = NewBlockCommentStyle("block", "/*", "*/", "")
+	// StarBlockComment is a block comment with ` * ` before each line.
+	StarBlockComment = NewBlockCommentStyle("block-star", "/*", "*/", " * ")
+	// HashComment is `# text`, used by shell scripts, YAML, etc.
+	HashComment = NewLineCommentStyle("hash", "# ")
+	// DashComment is `-- text`, used by SQL and Lua.
+	DashComment = NewLineCommentStyle("dash", "-- ")
+	// XMLComment is `<!-- text -->`.
+	XMLComment = NewBlockCommentStyle("xml", "<!--", "-->", "")
+)
+
+var builtInCommentStyles = []CommentStyle{LineComment, BlockComment, StarBlockComment, HashComment, DashComment, XMLComment}
+
+// goCommentStyles are built-in styles for Go files. The first one is used for new headers.
+var goCommentStyles = []CommentStyle{LineComment, StarBlockComment, BlockComment}
+
+// CommentStyleType is kept for compatibility. See CommentStyle.
+type CommentStyleType int
+
+const (
+	DoubleSlash CommentStyleType = iota
+	MultiLine
+	MultiLineStar
+)
+
+// Style returns the comment style of the type.
+func (t CommentStyleType) Style() CommentStyle {
+	switch t {
+	case MultiLine:
+		return BlockComment
+	case MultiLineStar:
+		return StarBlockComment
+	default:
+		return LineComment
+	}
+}
+
+// NewLineCommentStyle returns a style of line comments. The prefix is written before each line,
+// trailing spaces of the prefix are not written for empty lines and are optional in existing headers.
+func NewLineCommentStyle(name, prefix string) CommentStyle {
+	return &lineCommentStyle{name: name, prefix: prefix}
+}
+
+type lineCommentStyle struct {
+	name, prefix string
+}
+
+func (s *lineCommentStyle) Name() string {
+	return s.name
+}
+
+// Detect requires the exact prefix on non-empty lines, so "// " and "//" styles can be told apart.
+func (s *lineCommentStyle) Detect(comment string) bool {
+	marker := strings.TrimSpace(s.prefix)
+	separator := strings.TrimPrefix(s.prefix, marker)
+
+	for _, line := range strings.Split(comment, "\n") {
+		text, ok := strings.CutPrefix(strings.TrimSpace(line), marker)
+		if !ok {
+			return false
+		}
+		if text == "" {
+			continue
+		}
+		if separator == "" && strings.TrimLeft(text, " \t") != text || !strings.HasPrefix(text, separator) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *lineCommentStyle) Strip(comment string) string {
+	marker := strings.TrimSpace(s.prefix)
+
+	return trimEachLine(comment, func(line string) string {
+		line = strings.TrimSpace(line)
+		if text, ok := strings.CutPrefix(line, s.prefix); ok {
+			return text
+		}
+		text, _ := strings.CutPrefix(line, marker)
+		return strings.TrimPrefix(text, " ")
+	})
+}
+
+func (s *lineCommentStyle) Render(text string) string {
+	return trimEachLine(text, func(line string) string {
+		return strings.TrimRight(s.prefix+line, " \t")
+	})
+}
+
+// NewBlockCommentStyle returns a style of block comments that start and end with the markers on separate lines.
+// If linePrefix is not empty, each line is decorated with it, for example " * ". Such comments are detected
+// by the trimmed line prefix.
+func NewBlockCommentStyle(name, start, end, linePrefix string) CommentStyle {
+	return &blockCommentStyle{name: name, start: start, end: end, linePrefix: linePrefix}
+}
+
+type blockCommentStyle struct {
+	name, start, end, linePrefix string
+}
+
+func (s *blockCommentStyle) Name() string {
+	return s.name
+}
+
+func (s *blockCommentStyle) Detect(comment string) bool {
+	comment = strings.TrimSpace(comment)
+
+	if len(comment) < len(s.start)+len(s.end) || !strings.HasPrefix(comment, s.start) || !strings.HasSuffix(comment, s.end) {
+		return false
+	}
+
+	if s.linePrefix == "" {
+		return true
+	}
+
+	_, ok := s.stripLines(s.body(comment))
+
+	return ok
+}
+
+func (s *blockCommentStyle) Strip(comment string) string {
+	text := s.body(strings.TrimSpace(comment))
+
+	if s.linePrefix != "" {
+		text, _ = s.stripLines(text)
+	}
+
+	return trimEachLine(text, func(line string) string {
+		return strings.TrimRight(line, " \t")
+	})
+}
+
+func (s *blockCommentStyle) Render(text string) string {
+	if s.linePrefix == "" {
+		return s.start + "\n" + text + "\n" + s.end
+	}
+
+	text = trimEachLine(text, func(line string) string {
+		return strings.TrimRight(s.linePrefix+line, " \t")
+	})
+
+	// Align the end marker with the decoration, e.g. ` */`.
+	indent := s.linePrefix[:len(s.linePrefix)-len(strings.TrimLeft(s.linePrefix, " \t"))]
+
+	return s.start + "\n" + text + "\n" + indent + s.end
+}
+
+// body returns the text between the markers. It is empty if the comment is too short to have them,
+// e.g. if a file has no header.
+func (s *blockCommentStyle) body(comment string) string {
+	if len(comment) < len(s.start)+len(s.end) {
+		return ""
+	}
+	return comment[len(s.start) : len(comment)-len(s.end)]
+}
+
+// stripLines removes the line decoration. It reports whether at least one line is decorated.
+func (s *blockCommentStyle) stripLines(text string) (string, bool) {
+	marker := strings.TrimSpace(s.linePrefix)
+
+	var handled = false
+	return trimEachLine(text, func(line string) string {
+		var trimmed = strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, marker) {
+			return line
+		}
+		if v, ok := strings.CutPrefix(trimmed, marker+" "); ok {
+			handled = true
+			return v
+		}
+		var res, _ = strings.CutPrefix(trimmed, marker)
+		return res
+	}), handled
+}
+
+// detectStyle returns the style of the comment. Custom styles are checked first.
+// The first of styles is returned for an empty comment.
+func (a *Analyzer) detectStyle(styles []CommentStyle, comment string) CommentStyle {
+	if comment != "" {
+		for _, list := range [][]CommentStyle{a.Settings.CommentStyles, styles} {
+			for _, style := range list {
+				if style.Detect(comment) {
+					return style
+				}
+			}
+		}
+	}
+
+	return styles[0]
+}
+
+// preferredStyle returns the style of fixes for a comment in the style. Settings.CommentStyle is used
+// if the file type supports it, custom styles are supported by files with `//` comments.
+func (a *Analyzer) preferredStyle(styles []CommentStyle, style CommentStyle) CommentStyle {
+	preferred := a.Settings.CommentStyle
+	if preferred == nil {
+		return style
+	}
+
+	if slices.Contains(styles, preferred) {
+		return preferred
+	}
+
+	if slices.Contains(a.Settings.CommentStyles, preferred) && slices.Contains(styles, LineComment) {
+		return preferred
+	}
+
+	return style
+}
+
+// wrongStyle returns a diagnostic for a matching header written in another style than preferred.
+// The fix replaces the header with newText.
+func wrongStyle(style, preferred CommentStyle, newText string) *analysis.Diagnostic {
+	return &analysis.Diagnostic{
+		Message:  fmt.Sprintf("header uses %v comments, expected %v", style.Name(), preferred.Name()),
+		Category: CategoryWrongCommentStyle,
+		SuggestedFixes: []analysis.SuggestedFix{{
+			TextEdits: []analysis.TextEdit{{
+				NewText: []byte(newText),
+			}},
+		}},
+	}
+}
+
+// commentStyleByName returns a built-in or a custom style. Nil is returned for "any".
+func commentStyleByName(name string, custom []CommentStyle) (CommentStyle, error) {
+	if name == "" || name == "any" {
+		return nil, nil
+	}
+
+	for _, style := range slices.Concat(custom, builtInCommentStyles) {
+		if style.Name() == name {
+			return style, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown comment style %q", name)
+}
+
+func trimEachLine(input string, trimFunc func(string) string) string {
+	lines := strings.Split(input, "\n")
+
+	for i, line := range lines {
+		lines[i] = trimFunc(line)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,5 @@
+// Copyright 2026 OtherCompany
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package commentstyle
@@ -0,0 +1,4 @@
+# -*- coding: utf-8 -*-
+# Copyright 2024 Acme
+
+print("hi")
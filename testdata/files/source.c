@@ -0,0 +1,5 @@
+/*
+ * Copyright 2020 Acme
+ */
+
+int x;
@@ -0,0 +1,4 @@
+// Copyright Other Corp
+// SPDX-License-Identifier: GPL-3.0
+
+package listvar
@@ -0,0 +1,4 @@
+// Copyright Acme Ltd.
+// SPDX-License-Identifier: GPL-3.0
+
+package listvar
@@ -0,0 +1,5 @@
+// Copyright Acme
+//
+// SPDX-License-Identifier: MIT
+
+package preferstyle
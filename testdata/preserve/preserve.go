@@ -0,0 +1,5 @@
+// Copyright (c) 2017 Foo Corp
+// Author: John Doe
+// SPDX-License-Identifier: Apache-2.0
+
+package preserve
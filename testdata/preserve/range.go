@@ -0,0 +1,7 @@
+/*
+ * Copyright (c) 2015-2024 Acme
+ * Author: Jane Doe
+ * SPDX-License-Identifier: MIT
+ */
+
+package preserve
@@ -0,0 +1,3 @@
+// TEXT
+
+package regexpvalue
@@ -0,0 +1,7 @@
+// Copyright Acme
+// Modified by Alice
+// Contributor: Bob
+// Contributor: Carol
+// SPDX-License-Identifier: MIT
+
+package blocklines
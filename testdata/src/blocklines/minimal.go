@@ -0,0 +1,4 @@
+// Copyright Acme
+// SPDX-License-Identifier: MIT
+
+package blocklines
@@ -0,0 +1,6 @@
+// Copyright Acme
+// Contributor: Bob // want `template doesn.t match`
+// Modified by Alice
+// SPDX-License-Identifier: MIT
+
+package blocklines
@@ -0,0 +1,7 @@
+// Copyright 2026 Acme
+// Modified by Alice
+// Contributor: Bob
+// Contributor: Carol
+// SPDX-License-Identifier: MIT
+
+package blocks
@@ -0,0 +1,4 @@
+// Copyright 2026 Acme
+// SPDX-License-Identifier: MIT
+
+package blocks
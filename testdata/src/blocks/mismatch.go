@@ -0,0 +1,6 @@
+// Copyright 2026 Acme
+// Contributor: Bob // want `template doesn.t match`
+// Modified by Alice
+// SPDX-License-Identifier: MIT
+
+package blocks
@@ -0,0 +1,3 @@
+/*A 2021*/
+
+package brokenvar
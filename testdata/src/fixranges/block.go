@@ -0,0 +1,3 @@
+/* Copyright Other, SPDX-License-Identifier: MIT */ // want `template doesn't match`
+
+package fixranges
@@ -0,0 +1,9 @@
+//go:build !ignore
+
+// Copyright Other // want `template doesn't match`
+// SPDX-License-Identifier: MIT
+// Some other line
+//nolint:lll
+
+// Package fixranges is an example.
+package fixranges
@@ -0,0 +1,4 @@
+// Copyright Acme IncX // want `template doesn.t match: expected "Copyright Acme Inc.", found "Copyright Acme IncX`
+// SPDX-License-Identifier: MIT
+
+package listvar
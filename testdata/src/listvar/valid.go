@@ -0,0 +1,4 @@
+// Copyright Acme GmbH
+// SPDX-License-Identifier: MIT
+
+package listvar
@@ -0,0 +1,4 @@
+// Copyright Acme
+// SPDX-License-Identifier: MIT // want `template doesn't match: expected "SPDX-License-Identifier: Apache-2.0", found "SPDX-License-Identifier: MIT`
+
+package mismatchline
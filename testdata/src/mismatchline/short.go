@@ -0,0 +1,3 @@
+/* Copyright Acme */ // want `template doesn't match: expected "SPDX-License-Identifier: Apache-2.0" after the last line`
+
+package mismatchline
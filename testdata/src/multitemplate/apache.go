@@ -0,0 +1,4 @@
+// Copyright 2020 Acme
+// SPDX-License-Identifier: Apache-2.0
+
+package multitemplate
@@ -0,0 +1,6 @@
+/*
+Copyright 2020 Acme
+SPDX-License-Identifier: MIT
+*/
+
+package multitemplate
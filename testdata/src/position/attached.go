@@ -0,0 +1,2 @@
+// Copyright Acme // want `header must be separated from the package clause by a blank line`
+package position
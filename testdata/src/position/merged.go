@@ -0,0 +1,3 @@
+// Copyright Acme // want `header is merged with the package doc comment`
+// Package position is an example.
+package position
@@ -0,0 +1,3 @@
+// Copyright Acme
+
+package position
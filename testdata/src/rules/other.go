@@ -0,0 +1,3 @@
+// B 2020
+
+package rules
@@ -0,0 +1,3 @@
+// A 2020
+
+package rules
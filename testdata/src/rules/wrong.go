@@ -0,0 +1,3 @@
+/*B 2020*/ // want `template doesn't match`
+
+package rules
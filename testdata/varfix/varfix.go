@@ -0,0 +1,4 @@
+// other.mycompany.com
+// SPDX-License-Identifier: Apache-2.0
+
+package varfix
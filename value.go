@@ -19,6 +19,7 @@ package goheader
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -30,8 +31,21 @@ type Value interface {
 }
 
 func calculateValue(calculable Value, values map[string]Value) (string, error) {
+	return expandValue(calculable.Raw(), func(name string) (string, error) {
+		val := values[name]
+		if val == nil {
+			return "", fmt.Errorf("unknown value name %v", name)
+		}
+		if err := val.Calculate(values); err != nil {
+			return "", err
+		}
+		return val.Get(), nil
+	})
+}
+
+// expandValue replaces references to other values in r with results of lookup.
+func expandValue(r string, lookup func(name string) (string, error)) (string, error) {
 	sb := strings.Builder{}
-	r := calculable.Raw()
 	var endIndex int
 	var startIndex int
 	for startIndex = strings.Index(r, "{{"); startIndex >= 0; startIndex = strings.Index(r, "{{") {
@@ -42,14 +56,11 @@ func calculateValue(calculable Value, values map[string]Value) (string, error) {
 		}
 		subVal := strings.TrimSpace(r[startIndex+2 : endIndex])
 		subVal, _ = strings.CutPrefix(subVal, ".")
-		if val := values[subVal]; val != nil {
-			if err := val.Calculate(values); err != nil {
-				return "", err
-			}
-			sb.WriteString(val.Get())
-		} else {
-			return "", fmt.Errorf("unknown value name %v", subVal)
+		v, err := lookup(subVal)
+		if err != nil {
+			return "", err
 		}
+		sb.WriteString(v)
 		endIndex += 2
 		r = r[endIndex:]
 	}
@@ -94,12 +105,18 @@ func (c *ConstValue) String() string {
 
 type RegexpValue struct {
 	RawValue, Value string
+	// Fix is a literal used instead of the regexp in suggested fixes. Can refer to other values.
+	Fix string
+	// Keep means that suggested fixes reuse the text matched by the value in the existing header.
+	Keep bool
 }
 
 func (r *RegexpValue) Clone() Value {
 	return &RegexpValue{
 		Value:    r.Value,
 		RawValue: r.RawValue,
+		Fix:      r.Fix,
+		Keep:     r.Keep,
 	}
 }
 
@@ -126,5 +143,136 @@ func (r *RegexpValue) String() string {
 	return r.Get()
 }
 
+// YearRangePolicy defines how suggested fixes update year ranges
+type YearRangePolicy string
+
+const (
+	// ExtendYearRange keeps the first year and updates the last one: 2015-2024 becomes 2015-2026.
+	ExtendYearRange YearRangePolicy = "extend"
+	// ReplaceYearRange replaces the range with the last year: 2015-2024 becomes 2026.
+	ReplaceYearRange YearRangePolicy = "replace"
+	// FirstYearOnly keeps only the first year: 2015-2024 becomes 2015.
+	FirstYearOnly YearRangePolicy = "first-year"
+	// YearList appends the last year to a comma separated list: 2015, 2019 becomes 2015, 2019, 2026.
+	YearList YearRangePolicy = "list"
+)
+
+// ParseYearRangePolicy returns the policy by its name. Empty name means ExtendYearRange.
+func ParseYearRangePolicy(s string) (YearRangePolicy, error) {
+	switch p := YearRangePolicy(s); p {
+	case "":
+		return ExtendYearRange, nil
+	case ExtendYearRange, ReplaceYearRange, FirstYearOnly, YearList:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown year range policy %q", s)
+	}
+}
+
+// YearRangeValue matches a year or a range of years ending with the year from RawValue,
+// for example `2026` or `2015-2026`.
+type YearRangeValue struct {
+	// RawValue is the last year of the range. Usually refers to another value like {{.YEAR}}.
+	RawValue, Value string
+	// Fix is the last year used in suggested fixes. RawValue is used if it is empty.
+	Fix string
+	// Policy defines which ranges are valid and how fixes update them.
+	Policy YearRangePolicy
+}
+
+func (y *YearRangeValue) Calculate(values map[string]Value) error {
+	v, err := calculateValue(y, values)
+	if err != nil {
+		return err
+	}
+	y.Value = v
+	return nil
+}
+
+func (y *YearRangeValue) Raw() string {
+	return y.RawValue
+}
+
+// Get returns the regexp for the range.
+func (y *YearRangeValue) Get() string {
+	year := y.Value
+	if year == "" {
+		year = y.RawValue
+	}
+	switch y.Policy {
+	case FirstYearOnly:
+		return fmt.Sprintf(`((20\d\d\-%v)|(20\d\d))`, year)
+	case YearList:
+		return fmt.Sprintf(`((20\d\d(\-20\d\d)?, )*(20\d\d\-)?%v)`, year)
+	default:
+		return fmt.Sprintf(`((20\d\d\-%v)|(%v))`, year, year)
+	}
+}
+
+func (y *YearRangeValue) Clone() Value {
+	return &YearRangeValue{
+		RawValue: y.RawValue,
+		Value:    y.Value,
+		Fix:      y.Fix,
+		Policy:   y.Policy,
+	}
+}
+
+func (y *YearRangeValue) String() string {
+	return y.Get()
+}
+
+// ListValue matches any of the literals. Fixes use Fix or the first literal.
+type ListValue struct {
+	Values []string
+	// Fix is the literal used in suggested fixes. The first of Values is used if it is empty.
+	// Can refer to other values.
+	Fix string
+	// Keep means that suggested fixes reuse the text matched by the value in the existing header.
+	Keep bool
+}
+
+func (l *ListValue) Calculate(map[string]Value) error {
+	if len(l.Values) == 0 {
+		return errors.New("list value must have at least one literal")
+	}
+	return nil
+}
+
+// Raw returns the regexp for the literals.
+func (l *ListValue) Raw() string {
+	var quoted = make([]string, len(l.Values))
+	for i, v := range l.Values {
+		quoted[i] = regexp.QuoteMeta(v)
+	}
+	return "(?:" + strings.Join(quoted, "|") + ")"
+}
+
+func (l *ListValue) Get() string {
+	return l.Raw()
+}
+
+func (l *ListValue) Clone() Value {
+	return &ListValue{
+		Values: l.Values,
+		Fix:    l.Fix,
+		Keep:   l.Keep,
+	}
+}
+
+func (l *ListValue) String() string {
+	return l.Get()
+}
+
+// fix returns the literal for suggested fixes.
+func (l *ListValue) fix() string {
+	if l.Fix != "" || len(l.Values) == 0 {
+		return l.Fix
+	}
+	return l.Values[0]
+}
+
 var _ Value = &ConstValue{}
 var _ Value = &RegexpValue{}
+var _ Value = &YearRangeValue{}
+var _ Value = &ListValue{}
@@ -0,0 +1,137 @@
+// Copyright (c) 2020-2025 Denis Tingaikin
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goheader
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// yearEdit is an outdated year in the header.
+type yearEdit struct {
+	// line is the index of the header line, column is the offset of the year in lineText.
+	line, column int
+	// lineText is the trimmed header line.
+	lineText        string
+	found, expected string
+}
+
+// outdatedYears returns the outdated years if the header matches the template with any years.
+// templateLine is the index of the template line with the first year.
+func (a *Analyzer) outdatedYears(tmplText, header string, vars map[string]Value) (years []yearEdit, templateLine int) {
+	c := newCapturer(vars, false)
+
+	expr, err := a.expression(tmplText, c.data())
+	if err != nil || c.err != nil {
+		return nil, 0
+	}
+
+	exp, err := regexp.Compile(anchor(expr, a.Settings.Match))
+	if err != nil {
+		return nil, 0
+	}
+
+	match := exp.FindStringSubmatchIndex(header)
+	if match == nil {
+		return nil, 0
+	}
+
+	for i, name := range c.names {
+		v := vars[name]
+		if !isYearValue(name, v) {
+			continue
+		}
+
+		index := 2 * exp.SubexpIndex(fmt.Sprintf("g%v", i))
+		start, end := match[index], match[index+1]
+		if start < 0 {
+			continue
+		}
+
+		found := header[start:end]
+		if valid, err := regexp.MatchString("^(?:"+v.Get()+")$", found); err != nil || valid {
+			continue
+		}
+
+		expected, err := newFixer(vars, map[string]string{name: found}).value(name)
+		if err != nil || expected == found {
+			return nil, 0
+		}
+
+		lineStart := strings.LastIndex(header[:start], "\n") + 1
+		lineEnd := strings.Index(header[lineStart:], "\n")
+		if lineEnd < 0 {
+			lineEnd = len(header) - lineStart
+		}
+		line := header[lineStart : lineStart+lineEnd]
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+
+		years = append(years, yearEdit{
+			line:     strings.Count(header[:start], "\n"),
+			column:   start - lineStart - indent,
+			lineText: strings.TrimSpace(line),
+			found:    found,
+			expected: expected,
+		})
+	}
+
+	if len(years) == 0 {
+		return nil, 0
+	}
+
+	return years, years[0].line - strings.Count(header[:match[0]], "\n")
+}
+
+// yearEdits returns edits replacing only the years. rawLine returns the position and the text of
+// the comment line with the index. It reports false if a year can't be found in the comment.
+func yearEdits(years []yearEdit, rawLine func(i int) (token.Pos, string)) ([]analysis.TextEdit, bool) {
+	var result []analysis.TextEdit
+
+	for _, year := range years {
+		pos, text := rawLine(year.line)
+
+		offset := strings.LastIndex(text, year.lineText)
+		if offset < 0 {
+			return nil, false
+		}
+
+		start := pos + token.Pos(offset+year.column)
+		result = append(result, analysis.TextEdit{
+			Pos:     start,
+			End:     start + token.Pos(len(year.found)),
+			NewText: []byte(year.expected),
+		})
+	}
+
+	return result, true
+}
+
+// setYearEdits replaces the fix of an outdated year diagnostic with edits of the years.
+// The diagnostic is reported at the first year.
+func setYearEdits(diag *analysis.Diagnostic, years []yearEdit, rawLine func(i int) (token.Pos, string)) {
+	edits, ok := yearEdits(years, rawLine)
+	if !ok {
+		return
+	}
+
+	diag.Pos, diag.End = edits[0].Pos, edits[0].End
+	diag.SuggestedFixes = []analysis.SuggestedFix{{TextEdits: edits}}
+}